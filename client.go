@@ -10,6 +10,7 @@ import (
 	"gopkg.in/h2non/gentleman.v2/plugins/cookies"
 	"gopkg.in/h2non/gentleman.v2/plugins/headers"
 	"gopkg.in/h2non/gentleman.v2/plugins/url"
+	"gopkg.in/h2non/gentleman.v2/utils"
 )
 
 // NewContext is a convenient alias to context.New factory.
@@ -32,15 +33,41 @@ type Client struct {
 
 	// Client entity has its own Middleware layer to compose and inherit behavior.
 	Middleware middleware.Middleware
+
+	// conns tracks the connections used by the client requests.
+	conns *connTracker
 }
 
 // New creates a new high level client entity
 // able to perform HTTP requests.
 func New() *Client {
+	conns := newConnTracker()
+	transports := utils.NewTransportCache()
+	transports.Setup = conns.setup
+
+	// Transports modified by the client and request plugins are owned by the client
+	ctx := context.New()
+	ctx.Set(utils.TransportsKey, transports)
 	return &Client{
-		Context:    context.New(),
+		Context:    ctx,
 		Middleware: middleware.New(),
+		conns:      conns,
+	}
+}
+
+// Stats returns a snapshot of the connection statistics per host ("host:port")
+// used by the client requests.
+//
+// Connections are tracked since dialed until closed on the transports owned
+// by the client, which are derived by transport plugins, such as pool.
+// Shared transports, such as the default one, custom transports,
+// and connections dialed via DialTLSContext are not tracked.
+// HTTP/2 connections are always reported as in use.
+func (c *Client) Stats() map[string]ConnStats {
+	if c.conns == nil {
+		return map[string]ConnStats{}
 	}
+	return c.conns.stats()
 }
 
 // Request creates a new Request based on the current Client
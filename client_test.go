@@ -2,15 +2,22 @@ package gentleman
 
 import (
 	gocontext "context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/nbio/st"
 	"gopkg.in/h2non/gentleman.v2/context"
+	"gopkg.in/h2non/gentleman.v2/plugins/compression"
+	"gopkg.in/h2non/gentleman.v2/plugins/proxy"
+	gtls "gopkg.in/h2non/gentleman.v2/plugins/tls"
 )
 
 func TestClientMiddlewareContext(t *testing.T) {
@@ -314,3 +321,95 @@ func TestClientWithCanceledContext(t *testing.T) {
 
 	st.Expect(t, strings.Contains(err.Error(), "context canceled"), true)
 }
+
+func TestClientTransportIsolation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Accept-Encoding"))
+	}))
+	defer ts.Close()
+
+	plain := New().URL(ts.URL)
+	uncompressed := New().URL(ts.URL)
+	uncompressed.Use(compression.Disable())
+	insecure := New().URL(ts.URL)
+	insecure.Use(gtls.Config(&tls.Config{InsecureSkipVerify: true}))
+	proxied := New().URL(ts.URL)
+	proxied.Use(proxy.Set(map[string]string{"ftp": "http://localhost:3128"}))
+
+	var wg sync.WaitGroup
+	transports := make([][]http.RoundTripper, 4)
+	for i := 0; i < 10; i++ {
+		for j, cli := range []*Client{plain, uncompressed, insecure, proxied} {
+			wg.Add(1)
+			go func(j int, cli *Client) {
+				defer wg.Done()
+				res, err := cli.Request().Send()
+				st.Expect(t, err, nil)
+				if j == 1 {
+					st.Expect(t, res.String(), "")
+				} else {
+					st.Expect(t, res.String(), "gzip")
+				}
+				transports[j] = append(transports[j], res.Context.Client.Transport)
+			}(j, cli)
+		}
+		wg.Wait()
+	}
+
+	// The default transport remains untouched
+	st.Expect(t, DefaultTransport.DisableCompression, false)
+	st.Expect(t, DefaultTransport.TLSClientConfig == nil || !DefaultTransport.TLSClientConfig.InsecureSkipVerify, true)
+	st.Expect(t, transports[0][0], http.RoundTripper(DefaultTransport))
+
+	// Each client reuses its own transport across requests
+	for j := 1; j < len(transports); j++ {
+		st.Expect(t, transports[j][0] != http.RoundTripper(DefaultTransport), true)
+		for _, transport := range transports[j] {
+			st.Expect(t, transport, transports[j][0])
+		}
+	}
+}
+
+func TestClientRequestTransportReuse(t *testing.T) {
+	var conns int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Accept-Encoding"))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	// Request plugins created per request reuse the transport owned by the client
+	cli := New().URL(ts.URL)
+	config := &tls.Config{InsecureSkipVerify: true}
+	for i := 0; i < 50; i++ {
+		req := cli.Request()
+		req.Use(compression.Disable())
+		req.Use(gtls.Config(config))
+		req.Use(proxy.Set(map[string]string{"ftp": "http://localhost:3128"}))
+		res, err := req.Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "")
+	}
+	st.Expect(t, atomic.LoadInt32(&conns), int32(1))
+
+	// Other client requests are not modified
+	res, err := cli.Request().Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "gzip")
+	st.Expect(t, res.Context.Client.Transport, http.RoundTripper(DefaultTransport))
+
+	// Requests with no client reuse the same transports too
+	for i := 0; i < 50; i++ {
+		req := NewRequest().URL(ts.URL)
+		req.Use(compression.Disable())
+		res, err := req.Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "")
+	}
+	st.Expect(t, atomic.LoadInt32(&conns), int32(3))
+}
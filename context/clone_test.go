@@ -0,0 +1,81 @@
+package context
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/nbio/st"
+)
+
+func TestContextCloneDeep(t *testing.T) {
+	ctx := New()
+	ctx.Request.URL, _ = url.Parse("http://foo.com/bar?a=1")
+	ctx.Request.Header.Set("X-Foo", "foo")
+	ctx.Request.Trailer = http.Header{"X-Trailer": {"foo"}}
+	ctx.Request.Form = url.Values{"a": {"1"}}
+	ctx.Response.Header = http.Header{"X-Res": {"foo"}}
+	ctx.Set("foo", "bar")
+
+	clone := ctx.Clone()
+	clone.Request.URL.Path = "/baz"
+	clone.Request.URL.RawQuery = "b=2"
+	clone.Request.Header.Set("X-Foo", "bar")
+	clone.Request.Trailer.Set("X-Trailer", "bar")
+	clone.Request.Form.Add("a", "2")
+	clone.Response.Header.Set("X-Res", "bar")
+	clone.Client.Timeout = 1
+	clone.Set("foo", "baz")
+
+	st.Expect(t, ctx.Request.URL.String(), "http://foo.com/bar?a=1")
+	st.Expect(t, ctx.Request.Header.Get("X-Foo"), "foo")
+	st.Expect(t, ctx.Request.Trailer.Get("X-Trailer"), "foo")
+	st.Expect(t, ctx.Request.Form["a"], []string{"1"})
+	st.Expect(t, ctx.Response.Header.Get("X-Res"), "foo")
+	st.Expect(t, int64(ctx.Client.Timeout), int64(0))
+	st.Expect(t, ctx.Get("foo"), "bar")
+
+	st.Expect(t, clone.Request.URL.String(), "http://foo.com/baz?b=2")
+	st.Expect(t, clone.Request.Header.Get("X-Foo"), "bar")
+	st.Expect(t, clone.Get("foo"), "baz")
+
+	// Shared fields
+	st.Expect(t, clone.Client.Transport, ctx.Client.Transport)
+	st.Expect(t, clone.Parent, ctx.Parent)
+}
+
+func TestContextCloneConcurrently(t *testing.T) {
+	parent := New()
+	parent.Set("parent", "foo")
+	ctx := New()
+	ctx.UseParent(parent)
+	ctx.Request.URL, _ = url.Parse("http://foo.com/bar")
+	ctx.Request.Header.Set("X-Foo", "foo")
+	ctx.Set("foo", "bar")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clone := ctx.Clone()
+			clone.Request.URL.Path = fmt.Sprintf("/%d", i)
+			clone.Request.Header.Set("X-Foo", fmt.Sprint(i))
+			clone.Response.Header.Set("X-Res", fmt.Sprint(i))
+			clone.Client.Timeout = 1
+			clone.Set("foo", i)
+
+			st.Expect(t, clone.Request.URL.Path, fmt.Sprintf("/%d", i))
+			st.Expect(t, clone.Request.Header.Get("X-Foo"), fmt.Sprint(i))
+			st.Expect(t, clone.Get("foo"), i)
+			st.Expect(t, clone.Get("parent"), "foo")
+		}(i)
+	}
+	wg.Wait()
+
+	st.Expect(t, ctx.Request.URL.Path, "/bar")
+	st.Expect(t, ctx.Request.Header.Get("X-Foo"), "foo")
+	st.Expect(t, ctx.Get("foo"), "bar")
+}
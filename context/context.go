@@ -14,8 +14,6 @@ import (
 	"net/http"
 	"net/url"
 	"time"
-
-	"gopkg.in/h2non/gentleman.v2/utils"
 )
 
 // Key stores the key identifier for the built-in context
@@ -178,24 +176,48 @@ func (c *Context) SetRequest(req *http.Request) {
 	c.Request = req.WithContext(c.Request.Context())
 }
 
-// Clone returns a clone of the current context.
+// Clone returns a deep clone of the current context, safe to be modified
+// concurrently with the current one.
+//
+// The store, the http.Client and the http.Request and http.Response
+// headers, trailers, URL and form values are copied.
+// The store values, the parent context, the client transport and cookie jar,
+// and the request and response bodies are shared.
 func (c *Context) Clone() *Context {
 	ctx := new(Context)
 	*ctx = *c
 
 	req := new(http.Request)
 	*req = *c.Request
+	req.Header = c.Request.Header.Clone()
+	req.Trailer = c.Request.Trailer.Clone()
+	req.Form = cloneValues(c.Request.Form)
+	req.PostForm = cloneValues(c.Request.PostForm)
+	if c.Request.URL != nil {
+		u := *c.Request.URL
+		req.URL = &u
+	}
 	ctx.Request = req
 	c.CopyTo(ctx)
 
-	res := new(http.Response)
-	*res = *c.Response
-	ctx.Response = res
+	if c.Response != nil {
+		res := new(http.Response)
+		*res = *c.Response
+		res.Header = c.Response.Header.Clone()
+		res.Trailer = c.Response.Trailer.Clone()
+		ctx.Response = res
+	}
+
+	if c.Client != nil {
+		cli := *c.Client
+		ctx.Client = &cli
+	}
 
 	return ctx
 }
 
-// CopyTo copies the current context store into a new Context.
+// CopyTo copies the current context store into a new Context,
+// preserving the parent context.Context for cancellation propagation.
 func (c *Context) CopyTo(newCtx *Context) {
 	store := Store{}
 
@@ -203,7 +225,7 @@ func (c *Context) CopyTo(newCtx *Context) {
 		store[key] = value
 	}
 
-	ctx := context.WithValue(context.Background(), Key, store)
+	ctx := context.WithValue(c.Request.Context(), Key, store)
 	newCtx.Request = newCtx.Request.WithContext(ctx)
 }
 
@@ -312,6 +334,18 @@ func (c *Context) SetCancelContext(ctx context.Context) *Context {
 	return c
 }
 
+// cloneValues returns a copy of the given url.Values, if present.
+func cloneValues(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+	clone := make(url.Values, len(values))
+	for key, value := range values {
+		clone[key] = append([]string(nil), value...)
+	}
+	return clone
+}
+
 // emptyContext creates a new empty context.Context
 func emptyContext() context.Context {
 	return context.WithValue(context.Background(), Key, Store{})
@@ -328,7 +362,7 @@ func createRequest() *http.Request {
 		ProtoMinor: 1,
 		Proto:      "HTTP/1.1",
 		Header:     make(http.Header),
-		Body:       utils.NopCloser(),
+		Body:       http.NoBody,
 	}
 	// Return shallow copy of Request with the new context
 	return req.WithContext(emptyContext())
@@ -342,6 +376,6 @@ func createResponse(req *http.Request) *http.Response {
 		Proto:      "HTTP/1.1",
 		Request:    req,
 		Header:     make(http.Header),
-		Body:       utils.NopCloser(),
+		Body:       http.NoBody,
 	}
 }
@@ -0,0 +1,131 @@
+package gentleman
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// CurlBodyLimit defines the maximum request body size in bytes inlined in curl commands.
+	// Larger or binary bodies are written to a temporary file passed via --data-binary @file.
+	CurlBodyLimit = 64 * 1024
+
+	// CurlBodyDir defines the directory where the body files of curl commands are written.
+	// Defaults to the default directory for temporary files.
+	CurlBodyDir = ""
+)
+
+// Curl returns the equivalent shell-escaped curl command line of the current request.
+//
+// The request phase middleware is run on a clone of the request with no network dialing,
+// so the command reflects the final method, URL, headers, cookies, authorization and body.
+// Note that body streams with no GetBody function are consumed.
+//
+// Body files are owned by the caller, who must remove them once the command is no longer needed,
+// e.g. by removing a dedicated CurlBodyDir.
+func (r *Request) Curl() (string, error) {
+	ctx, err := r.dryRun("request")
+	if err != nil {
+		return "", err
+	}
+
+	hr := ctx.Request
+	body, err := curlBody(hr)
+	if err != nil {
+		return "", err
+	}
+
+	method := hr.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	// Bodies are sent via POST by curl, so any other method must be explicit
+	args := []string{"curl"}
+	if method == http.MethodHead {
+		args = append(args, "--head")
+	} else if method != http.MethodGet || body != "" {
+		args = append(args, "-X", method)
+	}
+	args = append(args, hr.URL.String())
+
+	names := make([]string, 0, len(hr.Header))
+	for name := range hr.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range hr.Header[name] {
+			switch name {
+			case "Authorization":
+				if user, password, ok := hr.BasicAuth(); ok {
+					args = append(args, "-u", user+":"+password)
+					continue
+				}
+			case "Cookie":
+				args = append(args, "-b", value)
+				continue
+			}
+			args = append(args, "-H", name+": "+value)
+		}
+	}
+
+	if body != "" {
+		args = append(args, "--data-binary", body)
+	}
+
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	return strings.Join(args, " "), nil
+}
+
+// curlBody returns the --data-binary argument of the given request body,
+// writing it to a temporary file if large or binary.
+func curlBody(req *http.Request) (string, error) {
+	var body io.ReadCloser
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		body = reader
+	} else if req.Body != nil && req.Body != http.NoBody {
+		body = req.Body
+	}
+	if body == nil {
+		return "", nil
+	}
+	defer body.Close()
+
+	buf, err := ioutil.ReadAll(body)
+	if err != nil || len(buf) == 0 {
+		return "", err
+	}
+	if len(buf) <= CurlBodyLimit && utf8.Valid(buf) && !strings.ContainsRune(string(buf), 0) && buf[0] != '@' {
+		return string(buf), nil
+	}
+
+	file, err := ioutil.TempFile(CurlBodyDir, "gentleman-curl-*.body")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.Write(buf); err != nil {
+		return "", err
+	}
+	return "@" + file.Name(), nil
+}
+
+// shellQuote returns the given argument single-quoted for POSIX shells, if required.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@%+,") == "" {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
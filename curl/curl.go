@@ -0,0 +1,493 @@
+// Package curl implements a parser of curl command lines into gentleman requests.
+package curl
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/h2non/gentleman.v2"
+	c "gopkg.in/h2non/gentleman.v2/context"
+	"gopkg.in/h2non/gentleman.v2/plugins/auth"
+	"gopkg.in/h2non/gentleman.v2/plugins/multipart"
+	"gopkg.in/h2non/gentleman.v2/plugins/proxy"
+	"gopkg.in/h2non/gentleman.v2/plugins/redirect"
+	"gopkg.in/h2non/gentleman.v2/plugins/resolve"
+	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+	ptls "gopkg.in/h2non/gentleman.v2/plugins/tls"
+)
+
+// MaxRedirects defines the maximum number of redirects followed with -L, as curl does.
+var MaxRedirects = 50
+
+// insecure stores the TLS config used by -k, shared by the parsed requests so they reuse the same transport.
+var insecure = &tls.Config{InsecureSkipVerify: true}
+
+// shortFlags maps the supported short flags to their long name.
+var shortFlags = map[byte]string{
+	'X': "request",
+	'H': "header",
+	'd': "data",
+	'F': "form",
+	'u': "user",
+	'b': "cookie",
+	'x': "proxy",
+	'A': "user-agent",
+	'G': "get",
+	'k': "insecure",
+	'L': "location",
+	's': "silent",
+	'S': "show-error",
+	'v': "verbose",
+	'i': "include",
+}
+
+// valueFlags stores the supported long flags requiring a value.
+var valueFlags = map[string]bool{
+	"request":        true,
+	"header":         true,
+	"data":           true,
+	"data-ascii":     true,
+	"data-binary":    true,
+	"data-raw":       true,
+	"data-urlencode": true,
+	"form":           true,
+	"form-string":    true,
+	"user":           true,
+	"cookie":         true,
+	"proxy":          true,
+	"user-agent":     true,
+	"max-time":       true,
+	"resolve":        true,
+	"url":            true,
+}
+
+// boolFlags stores the supported long flags with no value.
+// Output related flags are accepted and ignored.
+var boolFlags = map[string]bool{
+	"compressed": true,
+	"insecure":   true,
+	"location":   true,
+	"get":        true,
+	"silent":     true,
+	"show-error": true,
+	"verbose":    true,
+	"include":    true,
+}
+
+// command stores the parsed curl command options.
+type command struct {
+	method    string
+	url       string
+	header    []string
+	data      []string
+	form      multipart.FormData
+	user      string
+	cookies   []*http.Cookie
+	proxy     string
+	maxTime   time.Duration
+	resolve   map[string][]string
+	get       bool
+	insecure  bool
+	location  bool
+	multipart bool
+}
+
+// Parse parses the given curl command line into a new configured gentleman.Request,
+// mapping the curl options into the equivalent plugins.
+//
+// Supported options: -X, -H, -d, --data-ascii, --data-binary, --data-raw,
+// --data-urlencode, -F, --form-string, -u, -b, -A, -G, --compressed, -k, -L,
+// --max-time, --resolve, -x/--proxy and --url. Output options such as -s, -S, -v and -i are ignored.
+// Any other option returns an error.
+func Parse(cmd string) (*gentleman.Request, error) {
+	args, err := split(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+
+	command := &command{}
+	if err := command.parse(args); err != nil {
+		return nil, err
+	}
+	return command.request()
+}
+
+func (cmd *command) parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			continue
+
+		case strings.HasPrefix(arg, "--"):
+			name := arg[2:]
+			value := ""
+			if valueFlags[name] {
+				if i+1 >= len(args) {
+					return fmt.Errorf("curl: option %s requires a value", arg)
+				}
+				i++
+				value = args[i]
+			} else if !boolFlags[name] {
+				return fmt.Errorf("curl: unsupported option %s", arg)
+			}
+			if err := cmd.set(name, value); err != nil {
+				return err
+			}
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Short flags can be combined, and take the value inline or as next argument
+			for j := 1; j < len(arg); j++ {
+				name, ok := shortFlags[arg[j]]
+				if !ok {
+					return fmt.Errorf("curl: unsupported option -%c", arg[j])
+				}
+				if !valueFlags[name] {
+					if err := cmd.set(name, ""); err != nil {
+						return err
+					}
+					continue
+				}
+
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 >= len(args) {
+						return fmt.Errorf("curl: option -%c requires a value", arg[j])
+					}
+					i++
+					value = args[i]
+				}
+				if err := cmd.set(name, value); err != nil {
+					return err
+				}
+				break
+			}
+
+		default:
+			if err := cmd.set("url", arg); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cmd.url == "" {
+		return fmt.Errorf("curl: no URL specified")
+	}
+	if cmd.multipart && len(cmd.data) > 0 {
+		return fmt.Errorf("curl: cannot mix form (-F) and data (-d) options")
+	}
+	return nil
+}
+
+func (cmd *command) set(name, value string) error {
+	switch name {
+	case "request":
+		cmd.method = strings.ToUpper(value)
+	case "header":
+		cmd.header = append(cmd.header, value)
+	case "user-agent":
+		cmd.header = append(cmd.header, "User-Agent: "+value)
+	case "data", "data-ascii", "data-binary", "data-raw", "data-urlencode":
+		data, err := parseData(name, value)
+		if err != nil {
+			return err
+		}
+		cmd.data = append(cmd.data, data)
+	case "form", "form-string":
+		cmd.multipart = true
+		return parseForm(&cmd.form, value, name == "form")
+	case "user":
+		if !strings.Contains(value, ":") {
+			return fmt.Errorf("curl: option --user requires a password, as in user:password")
+		}
+		cmd.user = value
+	case "cookie":
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("curl: cookie files are not supported: %s", value)
+		}
+		cmd.cookies = append(cmd.cookies, parseCookies(value)...)
+	case "proxy":
+		if !strings.Contains(value, "://") {
+			value = "http://" + value
+		}
+		cmd.proxy = value
+	case "max-time":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds < 0 {
+			return fmt.Errorf("curl: invalid --max-time value: %s", value)
+		}
+		cmd.maxTime = time.Duration(seconds * float64(time.Second))
+	case "resolve":
+		host, addrs, err := parseResolve(value)
+		if err != nil {
+			return err
+		}
+		if cmd.resolve == nil {
+			cmd.resolve = map[string][]string{}
+		}
+		cmd.resolve[host] = addrs
+	case "url":
+		if cmd.url != "" {
+			return fmt.Errorf("curl: multiple URLs are not supported")
+		}
+		if !strings.Contains(value, "://") {
+			value = "http://" + value
+		}
+		cmd.url = value
+	case "get":
+		cmd.get = true
+	case "insecure":
+		cmd.insecure = true
+	case "location":
+		cmd.location = true
+	}
+	// compressed: net/http already negotiates and decodes gzip transparently
+	return nil
+}
+
+// request creates the gentleman.Request of the parsed command.
+func (cmd *command) request() (*gentleman.Request, error) {
+	req := gentleman.NewRequest()
+
+	uri := cmd.url
+	if cmd.get && len(cmd.data) > 0 {
+		separator := "?"
+		if strings.Contains(uri, "?") {
+			separator = "&"
+		}
+		uri += separator + strings.Join(cmd.data, "&")
+	}
+	if _, err := url.Parse(uri); err != nil {
+		return nil, fmt.Errorf("curl: invalid URL: %s", err)
+	}
+	req.URL(uri)
+
+	contentType := false
+	seen := map[string]bool{}
+	for _, header := range cmd.header {
+		name, value, err := parseHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		key := http.CanonicalHeaderKey(name)
+		switch {
+		case value == "" && !strings.HasSuffix(header, ";"):
+			// "Name:" removes the header, while "Name;" sends it empty
+			req.DelHeader(name)
+		case seen[key]:
+			req.AddHeader(name, value)
+		default:
+			req.SetHeader(name, value)
+		}
+		seen[key] = true
+		contentType = contentType || key == "Content-Type"
+	}
+
+	if len(cmd.cookies) > 0 {
+		req.AddCookies(cmd.cookies)
+	}
+	if cmd.user != "" {
+		parts := strings.SplitN(cmd.user, ":", 2)
+		req.Use(auth.Basic(parts[0], parts[1]))
+	}
+
+	method := cmd.method
+	if cmd.multipart {
+		req.Form(cmd.form)
+	} else if len(cmd.data) > 0 && !cmd.get {
+		req.BodyString(strings.Join(cmd.data, "&"))
+		if !contentType {
+			req.Type("urlencoded")
+		}
+	} else if method == "" {
+		method = "GET"
+	}
+	if method == "" {
+		// Request bodies are sent via POST by default, as curl does
+		method = "POST"
+	}
+	req.Method(method)
+
+	if cmd.location {
+		req.Use(redirect.Config(redirect.Options{Limit: MaxRedirects}))
+	} else {
+		req.UseRequest(func(ctx *c.Context, h c.Handler) {
+			ctx.Client.CheckRedirect = func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}
+			h.Next(ctx)
+		})
+	}
+	if cmd.insecure {
+		req.Use(ptls.Config(insecure))
+	}
+	if cmd.maxTime > 0 {
+		req.Use(timeout.Request(cmd.maxTime))
+	}
+	if len(cmd.resolve) > 0 {
+		req.Use(resolve.New(resolve.Options{Hosts: cmd.resolve}))
+	}
+	if cmd.proxy != "" {
+		req.Use(proxy.Set(map[string]string{"http": cmd.proxy, "https": cmd.proxy}))
+	}
+
+	return req, nil
+}
+
+// parseHeader parses a "Name: value" or "Name;" header line.
+func parseHeader(header string) (string, string, error) {
+	if strings.HasSuffix(header, ";") && !strings.Contains(header, ":") {
+		return strings.TrimSuffix(header, ";"), "", nil
+	}
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", fmt.Errorf("curl: invalid header: %s", header)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// parseResolve parses a "host:port:addr[,addr]..." --resolve entry into its "host:port"
+// pattern and addresses. IPv6 hosts and addresses are enclosed in brackets.
+func parseResolve(value string) (string, []string, error) {
+	invalid := fmt.Errorf("curl: invalid --resolve value: %s", value)
+
+	rest := value
+	host := ""
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return "", nil, invalid
+		}
+		host, rest = rest[1:end], rest[end+1:]
+	} else if i := strings.Index(rest, ":"); i >= 0 {
+		host, rest = rest[:i], rest[i:]
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(rest, ":"), ":", 2)
+	if host == "" || !strings.HasPrefix(rest, ":") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", nil, invalid
+	}
+
+	var addrs []string
+	for _, addr := range strings.Split(parts[1], ",") {
+		addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		if addr == "" {
+			return "", nil, invalid
+		}
+		addrs = append(addrs, addr)
+	}
+	return net.JoinHostPort(host, parts[0]), addrs, nil
+}
+
+// parseData returns the request body data of the given --data* option.
+func parseData(name, value string) (string, error) {
+	switch name {
+	case "data-raw":
+		return value, nil
+	case "data-urlencode":
+		return encodeData(value)
+	}
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+
+	buf, err := readFile(value[1:])
+	if err != nil {
+		return "", err
+	}
+	if name == "data-binary" {
+		return string(buf), nil
+	}
+	// --data strips carriage returns and newlines from files, as curl does
+	return strings.NewReplacer("\r", "", "\n", "").Replace(string(buf)), nil
+}
+
+// encodeData returns the encoded data of a --data-urlencode option,
+// supporting the "content", "=content", "name=content", "@file" and "name@file" forms.
+func encodeData(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content := value[:i], value[i+1:]
+		if value[i] == '@' {
+			buf, err := readFile(content)
+			if err != nil {
+				return "", err
+			}
+			content = string(buf)
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// parseForm adds the given "name=value", "name=@file" or "name=<file" field into the form.
+func parseForm(form *multipart.FormData, field string, files bool) error {
+	parts := strings.SplitN(field, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("curl: invalid form field: %s", field)
+	}
+	name, value := parts[0], parts[1]
+
+	if files && (strings.HasPrefix(value, "@") || strings.HasPrefix(value, "<")) {
+		// Files are sent with their base name, as curl does, unless overridden by ;filename=.
+		// Other field options, such as ;type=, are discarded.
+		options := strings.Split(value[1:], ";")
+		path, filename := options[0], filepath.Base(options[0])
+		for _, option := range options[1:] {
+			if strings.HasPrefix(option, "filename=") {
+				filename = strings.Trim(strings.TrimPrefix(option, "filename="), `"`)
+			}
+		}
+
+		buf, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		if value[0] == '@' {
+			form.Files = append(form.Files, multipart.FormFile{Name: name, Reader: bytes.NewReader(buf), FileName: filename})
+			return nil
+		}
+		value = string(buf)
+	}
+
+	if form.Data == nil {
+		form.Data = multipart.DataFields{}
+	}
+	form.Data[name] = append(form.Data[name], value)
+	return nil
+}
+
+// parseCookies parses the cookies of a "name=value; name2=value2" string.
+func parseCookies(value string) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, pair := range strings.Split(value, ";") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		cookies = append(cookies, &http.Cookie{Name: parts[0], Value: parts[1]})
+	}
+	return cookies
+}
+
+func readFile(path string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("curl: cannot read file: %s", err)
+	}
+	return buf, nil
+}
@@ -0,0 +1,274 @@
+package curl
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+type echo struct {
+	method string
+	url    string
+	header http.Header
+	body   string
+	form   map[string][]string
+	file   string
+	name   string
+	user   string
+	pass   string
+}
+
+func newServer(req *echo) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req.method, req.url, req.header = r.Method, r.URL.String(), r.Header
+		req.user, req.pass, _ = r.BasicAuth()
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			r.ParseMultipartForm(1024)
+			req.form = r.MultipartForm.Value
+			if file, header, err := r.FormFile("upload"); err == nil {
+				buf, _ := ioutil.ReadAll(file)
+				req.file, req.name = string(buf), header.Filename
+			}
+		} else {
+			buf, _ := ioutil.ReadAll(r.Body)
+			req.body = string(buf)
+		}
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestParse(t *testing.T) {
+	req := &echo{}
+	ts := newServer(req)
+	defer ts.Close()
+
+	r, err := Parse(`curl -X PUT '` + ts.URL + `/users/1?foo=bar' \
+  -H 'Accept: application/json' -H "X-Quote: \"a b\"" -H 'Accept: text/plain' \
+  -A custom/1.0 -u 'user:pa ss' -b 'session=abc; theme=dark' \
+  --data-raw '{"name":"foo"}' -H 'Content-Type: application/json' \
+  --compressed -sS --max-time 5`)
+	st.Expect(t, err, nil)
+
+	res, err := r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, req.method, "PUT")
+	st.Expect(t, req.url, "/users/1?foo=bar")
+	st.Expect(t, req.header["Accept"], []string{"application/json", "text/plain"})
+	st.Expect(t, req.header.Get("X-Quote"), `"a b"`)
+	st.Expect(t, req.header.Get("User-Agent"), "custom/1.0")
+	st.Expect(t, req.header.Get("Content-Type"), "application/json")
+	st.Expect(t, req.header.Get("Cookie"), "session=abc; theme=dark")
+	st.Expect(t, req.user, "user")
+	st.Expect(t, req.pass, "pa ss")
+	st.Expect(t, req.body, `{"name":"foo"}`)
+}
+
+func TestParseData(t *testing.T) {
+	req := &echo{}
+	ts := newServer(req)
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "data.txt")
+	st.Expect(t, ioutil.WriteFile(path, []byte("a=1\nb=2\n"), 0644), nil)
+
+	r, err := Parse(`curl ` + ts.URL + ` -d foo=bar -d @` + path + ` --data-urlencode 'q=a b&c'`)
+	st.Expect(t, err, nil)
+	_, err = r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, req.method, "POST")
+	st.Expect(t, req.header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	st.Expect(t, req.body, "foo=bar&a=1b=2&q=a+b%26c")
+
+	r, err = Parse(`curl -G ` + ts.URL + `/search?x=1 --data-urlencode 'q=a b'`)
+	st.Expect(t, err, nil)
+	_, err = r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, req.method, "GET")
+	st.Expect(t, req.url, "/search?x=1&q=a+b")
+	st.Expect(t, req.body, "")
+}
+
+func TestParseForm(t *testing.T) {
+	req := &echo{}
+	ts := newServer(req)
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	st.Expect(t, ioutil.WriteFile(path, []byte("content"), 0644), nil)
+
+	r, err := Parse(`curl ` + ts.URL + ` -F name=foo -F 'upload=@` + path + `;type=text/plain' --form-string 'raw=@literal'`)
+	st.Expect(t, err, nil)
+	_, err = r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, req.method, "POST")
+	st.Expect(t, req.form["name"], []string{"foo"})
+	st.Expect(t, req.form["raw"], []string{"@literal"})
+	st.Expect(t, req.file, "content")
+	st.Expect(t, req.name, "upload.txt")
+
+	// Files are sent again
+	req.file = ""
+	_, err = r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, req.file, "content")
+
+	// The file name can be overridden
+	r, err = Parse(`curl ` + ts.URL + ` -F 'upload=@` + path + `;filename=data.txt'`)
+	st.Expect(t, err, nil)
+	_, err = r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, req.file, "content")
+	st.Expect(t, req.name, "data.txt")
+}
+
+func TestParseRedirect(t *testing.T) {
+	req := &echo{}
+	ts := newServer(req)
+	defer ts.Close()
+
+	r, err := Parse("curl " + ts.URL + "/redirect")
+	st.Expect(t, err, nil)
+	res, err := r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 302)
+
+	r, err = Parse("curl -sL " + ts.URL + "/redirect")
+	st.Expect(t, err, nil)
+	res, err = r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, req.url, "/target")
+}
+
+func TestParseInsecure(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	r, err := Parse("curl " + ts.URL)
+	st.Expect(t, err, nil)
+	_, err = r.Send()
+	st.Reject(t, err, nil)
+
+	r, err = Parse("curl -k " + ts.URL)
+	st.Expect(t, err, nil)
+	res, err := r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "ok")
+
+	// The default transport remains untouched
+	st.Expect(t, gentleman.DefaultTransport.TLSClientConfig == nil || !gentleman.DefaultTransport.TLSClientConfig.InsecureSkipVerify, true)
+}
+
+func TestParseMaxTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	r, err := Parse("curl --max-time 0.05 " + ts.URL)
+	st.Expect(t, err, nil)
+	_, err = r.Send()
+	st.Reject(t, err, nil)
+}
+
+func TestParseResolve(t *testing.T) {
+	req := &echo{}
+	ts := newServer(req)
+	defer ts.Close()
+	port := ts.URL[strings.LastIndex(ts.URL, ":")+1:]
+
+	r, err := Parse("curl --resolve api.example.com:" + port + ":[::1],127.0.0.1 http://api.example.com:" + port + "/foo")
+	st.Expect(t, err, nil)
+	res, err := r.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "ok")
+	st.Expect(t, req.url, "/foo")
+}
+
+func TestParseResolveValues(t *testing.T) {
+	cases := []struct {
+		value string
+		host  string
+		addrs []string
+	}{
+		{"example.com:443:127.0.0.1", "example.com:443", []string{"127.0.0.1"}},
+		{"*.example.com:*:10.0.0.1,10.0.0.2", "*.example.com:*", []string{"10.0.0.1", "10.0.0.2"}},
+		{"example.com:443:[2001:db8::1]", "example.com:443", []string{"2001:db8::1"}},
+		{"example.com:443:[::1],127.0.0.1", "example.com:443", []string{"::1", "127.0.0.1"}},
+		{"[::1]:443:127.0.0.1", "[::1]:443", []string{"127.0.0.1"}},
+		{"[2001:db8::1]:80:[::1]", "[2001:db8::1]:80", []string{"::1"}},
+	}
+	for _, test := range cases {
+		host, addrs, err := parseResolve(test.value)
+		st.Expect(t, err, nil)
+		st.Expect(t, host, test.host)
+		st.Expect(t, addrs, test.addrs)
+	}
+
+	for _, value := range []string{"a:80", "a::127.0.0.1", ":80:127.0.0.1", "[::1:80:127.0.0.1", "[::1]80:127.0.0.1", "a:80:1.1.1.1,"} {
+		_, _, err := parseResolve(value)
+		st.Expect(t, err.Error(), "curl: invalid --resolve value: "+value)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"curl":                               "curl: no URL specified",
+		"curl http://a http://b":             "curl: multiple URLs are not supported",
+		"curl --fail http://a":               "curl: unsupported option --fail",
+		"curl -Z http://a":                   "curl: unsupported option -Z",
+		"curl http://a -X":                   "curl: option -X requires a value",
+		"curl http://a --header":             "curl: option --header requires a value",
+		"curl -u user http://a":              "curl: option --user requires a password, as in user:password",
+		"curl -b cookies.txt http://a":       "curl: cookie files are not supported: cookies.txt",
+		"curl -H invalid http://a":           "curl: invalid header: invalid",
+		"curl --max-time foo http://a":       "curl: invalid --max-time value: foo",
+		"curl --resolve a:80 http://a":       "curl: invalid --resolve value: a:80",
+		"curl -d a=1 -F b=2 http://a":        "curl: cannot mix form (-F) and data (-d) options",
+		"curl 'http://a":                     "curl: unterminated single quote",
+		`curl "http://a`:                     "curl: unterminated double quote",
+		"curl -d @" + os.DevNull + "x http:": "curl: cannot read file: open " + os.DevNull + "x: no such file or directory",
+	}
+	for cmd, msg := range cases {
+		_, err := Parse(cmd)
+		st.Reject(t, err, nil)
+		if err != nil {
+			st.Expect(t, err.Error(), msg)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	args, err := split(`curl -H 'a b' "c \"d\" \$e" f\ g $'h\ni\'' \
+  --data-raw x'y'"z"`)
+	st.Expect(t, err, nil)
+	st.Expect(t, args, []string{"curl", "-H", "a b", `c "d" $e`, "f g", "h\ni'", "--data-raw", "xyz"})
+}
+
+func TestCurlRoundTrip(t *testing.T) {
+	req := gentleman.NewRequest().Method("PATCH").URL("http://localhost/foo?a=1").
+		SetHeader("X-Foo", "it's").BodyString(`{"a":1}`)
+	cmd, err := req.Curl()
+	st.Expect(t, err, nil)
+
+	parsed, err := Parse(cmd)
+	st.Expect(t, err, nil)
+	out, err := parsed.Curl()
+	st.Expect(t, err, nil)
+	st.Expect(t, strings.Replace(out, "-H 'Content-Type: application/x-www-form-urlencoded' ", "", 1), cmd)
+}
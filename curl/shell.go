@@ -0,0 +1,118 @@
+package curl
+
+import (
+	"errors"
+	"strings"
+)
+
+// split splits the given command line into arguments following POSIX shell
+// quoting rules, including ANSI-C $'...' quoting and escaped line breaks.
+func split(cmd string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+
+	for i := 0; i < len(cmd); i++ {
+		ch := cmd[i]
+		switch {
+		case ch == '\\':
+			if i+1 < len(cmd) {
+				i++
+				// Escaped line breaks are line continuations
+				if cmd[i] == '\n' {
+					continue
+				}
+				if cmd[i] == '\r' && i+1 < len(cmd) && cmd[i+1] == '\n' {
+					i++
+					continue
+				}
+				arg.WriteByte(cmd[i])
+				inArg = true
+			}
+
+		case ch == '\'':
+			end := strings.IndexByte(cmd[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("curl: unterminated single quote")
+			}
+			arg.WriteString(cmd[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+
+		case ch == '$' && i+1 < len(cmd) && cmd[i+1] == '\'':
+			n, err := ansiQuote(cmd[i+2:], &arg)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+			inArg = true
+
+		case ch == '"':
+			n, err := doubleQuote(cmd[i+1:], &arg)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			inArg = true
+
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			arg.WriteByte(ch)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// doubleQuote writes the content of a double quoted string, returning
+// the number of bytes consumed including the closing quote.
+func doubleQuote(s string, arg *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return i + 1, nil
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+				i++
+				if s[i] != '\n' {
+					arg.WriteByte(s[i])
+				}
+				continue
+			}
+		}
+		arg.WriteByte(s[i])
+	}
+	return 0, errors.New("curl: unterminated double quote")
+}
+
+// ansiQuote writes the content of an ANSI-C $'...' quoted string, returning
+// the number of bytes consumed including the closing quote.
+func ansiQuote(s string, arg *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"', '0': 0}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return i + 1, nil
+		case '\\':
+			if i+1 < len(s) {
+				if ch, ok := escapes[s[i+1]]; ok {
+					arg.WriteByte(ch)
+					i++
+					continue
+				}
+			}
+		}
+		arg.WriteByte(s[i])
+	}
+	return 0, errors.New("curl: unterminated ANSI-C quote")
+}
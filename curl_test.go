@@ -0,0 +1,90 @@
+package gentleman
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestRequestCurl(t *testing.T) {
+	cli := New()
+	cli.BaseURL("http://localhost:8080")
+	cli.SetHeader("X-Client", "foo")
+
+	req := cli.Post()
+	req.Path("/users/:id").Param("id", "123").SetQuery("q", "it's")
+	req.SetHeader("User-Agent", "test")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "bar"})
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		ctx.Request.SetBasicAuth("user", "pass")
+		h.Next(ctx)
+	})
+	req.JSON(map[string]string{"name": "foo"})
+
+	cmd, err := req.Curl()
+	st.Expect(t, err, nil)
+	st.Expect(t, cmd, `curl -X POST 'http://localhost:8080/users/123?q=it%27s' `+
+		`-u user:pass -H 'Content-Type: application/json' -b session=bar `+
+		`-H 'User-Agent: test' -H 'X-Client: foo' --data-binary '{"name":"foo"}`+"\n'")
+
+	// The request remains untouched
+	st.Expect(t, req.Context.Request.Header.Get("User-Agent"), UserAgent)
+	st.Expect(t, req.Context.Request.URL.String(), "")
+}
+
+func TestRequestCurlMethods(t *testing.T) {
+	cmd, err := NewRequest().URL("http://localhost/foo").DelHeader("User-Agent").Curl()
+	st.Expect(t, err, nil)
+	st.Expect(t, cmd, "curl http://localhost/foo")
+
+	cmd, err = NewRequest().Method("HEAD").URL("http://localhost/foo").DelHeader("User-Agent").Curl()
+	st.Expect(t, err, nil)
+	st.Expect(t, cmd, "curl --head http://localhost/foo")
+
+	// GET requests with a body must not be sent as POST by curl
+	cmd, err = NewRequest().URL("http://localhost/foo").DelHeader("User-Agent").BodyString("foo").Curl()
+	st.Expect(t, err, nil)
+	st.Expect(t, cmd, "curl -X GET http://localhost/foo --data-binary foo")
+}
+
+func TestRequestCurlBodyFile(t *testing.T) {
+	limit, dir := CurlBodyLimit, CurlBodyDir
+	defer func() { CurlBodyLimit, CurlBodyDir = limit, dir }()
+	CurlBodyLimit = 4
+	CurlBodyDir = t.TempDir()
+
+	req := NewRequest().Method("PUT").URL("http://localhost/foo").DelHeader("User-Agent").BodyString("hello world")
+	cmd, err := req.Curl()
+	st.Expect(t, err, nil)
+
+	prefix := "curl -X PUT http://localhost/foo --data-binary @"
+	st.Expect(t, strings.HasPrefix(cmd, prefix), true)
+	path := strings.TrimPrefix(cmd, prefix)
+	st.Expect(t, filepath.Dir(path), CurlBodyDir)
+	buf, err := ioutil.ReadFile(path)
+	st.Expect(t, err, nil)
+	st.Expect(t, string(buf), "hello world")
+}
+
+func TestRequestCurlError(t *testing.T) {
+	req := NewRequest()
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		h.Error(ctx, errors.New("oops"))
+	})
+	_, err := req.Curl()
+	st.Expect(t, err.Error(), "oops")
+}
+
+func TestShellQuote(t *testing.T) {
+	st.Expect(t, shellQuote("foo"), "foo")
+	st.Expect(t, shellQuote(""), "''")
+	st.Expect(t, shellQuote("a b"), "'a b'")
+	st.Expect(t, shellQuote("it's"), `'it'\''s'`)
+	st.Expect(t, shellQuote("$HOME"), "'$HOME'")
+}
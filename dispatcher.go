@@ -1,6 +1,10 @@
 package gentleman
 
 import (
+	"io"
+	"net/http/httptrace"
+	"time"
+
 	c "gopkg.in/h2non/gentleman.v2/context"
 )
 
@@ -43,6 +47,9 @@ func (d *Dispatcher) Dispatch() *c.Context {
 	// Reference to initial context
 	ctx := d.req.Context
 
+	// Expose the transaction timings via context
+	ctx.Set("$timings", newTimings())
+
 	// Execute tasks in order, stopping in case of error or explicit stop.
 	for _, task := range pipeline {
 		var stop bool
@@ -55,11 +62,24 @@ func (d *Dispatcher) Dispatch() *c.Context {
 }
 
 func (d *Dispatcher) doDial(ctx *c.Context) (*c.Context, bool) {
+	// Trace the network timings of the HTTP transaction
+	t := &tracer{}
+	traceCtx := httptrace.WithClientTrace(ctx.Request.Context(), t.trace())
+
+	// Track the state of the connections used by the client
+	if cli := d.req.Client; cli != nil && cli.conns != nil {
+		traceCtx = httptrace.WithClientTrace(traceCtx, cli.conns.trace())
+	}
+	req := ctx.Request.WithContext(traceCtx)
+
 	// Perform the request via ctx.Client
-	res, err := ctx.Client.Do(ctx.Request)
+	res, err := ctx.Client.Do(req)
+	timings := d.timings(ctx)
+	t.collect(timings)
+
 	ctx.Error = err
 	if err != nil {
-		ctx = d.req.Middleware.Run("error", ctx)
+		ctx = d.runPhase("error", ctx)
 		if ctx.Error != nil {
 			return ctx, true
 		}
@@ -67,6 +87,10 @@ func (d *Dispatcher) doDial(ctx *c.Context) (*c.Context, bool) {
 
 	// Assign response if present
 	if res != nil {
+		// Bodies of protocol upgrade responses are also writable, so they are left as is
+		if _, ok := res.Body.(io.Writer); !ok {
+			res.Body = &timedBody{ReadCloser: res.Body, timings: timings}
+		}
 		ctx.Response = res
 	}
 
@@ -123,10 +147,9 @@ func (d *Dispatcher) stop(ctx *c.Context) (*c.Context, bool) {
 		return ctx, false
 	}
 
-	mw := d.req.Middleware
-	ctx = mw.Run("stop", ctx)
+	ctx = d.runPhase("stop", ctx)
 	if ctx.Error != nil {
-		ctx = mw.Run("error", ctx)
+		ctx = d.runPhase("error", ctx)
 		if ctx.Error != nil {
 			return ctx, true
 		}
@@ -136,19 +159,35 @@ func (d *Dispatcher) stop(ctx *c.Context) (*c.Context, bool) {
 }
 
 func (d *Dispatcher) run(phase string, ctx *c.Context) (*c.Context, bool) {
-	mw := d.req.Middleware
-
 	// Run the middleware by phase
-	ctx = mw.Run(phase, ctx)
+	ctx = d.runPhase(phase, ctx)
 	if ctx.Error == nil {
 		return ctx, false
 	}
 
 	// Run error middleware
-	ctx = mw.Run("error", ctx)
+	ctx = d.runPhase("error", ctx)
 	if ctx.Error != nil {
 		return ctx, true
 	}
 
 	return ctx, false
 }
+
+// runPhase runs the middleware for the given phase, recording its duration.
+func (d *Dispatcher) runPhase(phase string, ctx *c.Context) *c.Context {
+	start := time.Now()
+	ctx = d.req.Middleware.Run(phase, ctx)
+	d.timings(ctx).Phases[phase] += time.Since(start)
+	return ctx
+}
+
+// timings returns the transaction timings stored in the given context.
+func (d *Dispatcher) timings(ctx *c.Context) *Timings {
+	timings, ok := ctx.Get("$timings").(*Timings)
+	if !ok {
+		timings = newTimings()
+		ctx.Set("$timings", timings)
+	}
+	return timings
+}
@@ -152,13 +152,14 @@ func (s *Layer) Run(phase string, ctx *c.Context) *c.Context {
 		}
 	}
 
+	// Snapshot the stack so plugins are not executed while holding the lock,
+	// since they may block (e.g: waiting for a concurrency slot)
 	s.mtx.Lock()
 	s.stack = filter(s.stack)
+	stack := s.stack
 	s.mtx.Unlock()
 
-	s.mtx.RLock()
-	defer s.mtx.RUnlock()
-	return trigger(phase, s.stack, ctx)
+	return trigger(phase, stack, ctx)
 }
 
 func filter(stack []plugin.Plugin) []plugin.Plugin {
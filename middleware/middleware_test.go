@@ -99,6 +99,38 @@ func TestAsyncMiddlewareCallChain(t *testing.T) {
 	}
 }
 
+func TestConcurrentBlockingMiddleware(t *testing.T) {
+	mw := New()
+
+	release := make(chan struct{})
+	mw.UseRequest(func(ctx *context.Context, h context.Handler) {
+		if ctx.GetString("wait") == "true" {
+			<-release
+		}
+		h.Next(ctx)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ctx := context.New()
+		ctx.Set("wait", "true")
+		mw.Run("request", ctx)
+		close(done)
+	}()
+
+	// A blocked call chain must not prevent other call chains from running
+	time.Sleep(10 * time.Millisecond)
+	mw.UseResponse(forward)
+	mw.Run("request", context.New())
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Blocked middleware call chain was not released")
+	}
+}
+
 func TestMultipleHandlerCalls(t *testing.T) {
 	mw := New()
 
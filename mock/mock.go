@@ -0,0 +1,162 @@
+// Package mock implements an HTTP mocking transport with a declarative
+// expectations builder API, designed to be installed via transport.Set().
+//
+// Mocks hold no global state: every mock is an isolated http.RoundTripper.
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoMatch is returned when an outgoing request does not match any pending expectation.
+var ErrNoMatch = errors.New("mock: no matching expectation")
+
+// T defines the testing interface used to report unconsumed expectations,
+// implemented by *testing.T.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Mock implements an http.RoundTripper replying to outgoing requests
+// based on the registered expectations.
+type Mock struct {
+	base     *url.URL
+	mtx      sync.Mutex
+	requests []*Request
+}
+
+// New creates a new mock for the given base URL, which defines the scheme,
+// host and optional path prefix matched by the expectations.
+func New(base string) *Mock {
+	u, err := url.Parse(base)
+	if err != nil {
+		panic(fmt.Sprintf("mock: invalid base URL %q: %s", base, err))
+	}
+	return &Mock{base: u}
+}
+
+// Request creates a new expectation for the given HTTP method and path pattern.
+//
+// The path pattern is a regular expression matching the whole request path,
+// where ":name" segments match any single path segment, e.g: "/users/:id".
+func (m *Mock) Request(method, path string) *Request {
+	req := newRequest(m, strings.ToUpper(method), path)
+	m.mtx.Lock()
+	m.requests = append(m.requests, req)
+	m.mtx.Unlock()
+	return req
+}
+
+// Get creates a new expectation for a GET request with the given path pattern.
+func (m *Mock) Get(path string) *Request {
+	return m.Request("GET", path)
+}
+
+// Post creates a new expectation for a POST request with the given path pattern.
+func (m *Mock) Post(path string) *Request {
+	return m.Request("POST", path)
+}
+
+// Put creates a new expectation for a PUT request with the given path pattern.
+func (m *Mock) Put(path string) *Request {
+	return m.Request("PUT", path)
+}
+
+// Patch creates a new expectation for a PATCH request with the given path pattern.
+func (m *Mock) Patch(path string) *Request {
+	return m.Request("PATCH", path)
+}
+
+// Delete creates a new expectation for a DELETE request with the given path pattern.
+func (m *Mock) Delete(path string) *Request {
+	return m.Request("DELETE", path)
+}
+
+// Head creates a new expectation for a HEAD request with the given path pattern.
+func (m *Mock) Head(path string) *Request {
+	return m.Request("HEAD", path)
+}
+
+// RoundTrip replies to the given request with the first pending expectation matching it.
+// Implements the http.RoundTripper interface.
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	var match *Request
+	for _, r := range m.requests {
+		if r.available() && r.matches(req, body) {
+			match = r
+			match.calls++
+			break
+		}
+	}
+	m.mtx.Unlock()
+
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, req.Method, req.URL)
+	}
+	res := match.response
+	if res == nil {
+		return nil, fmt.Errorf("mock: expectation %s %s has no reply", match.method, match.path)
+	}
+
+	if res.delay > 0 {
+		timer := time.NewTimer(res.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	return res.build(req), nil
+}
+
+// Pending returns the expectations not consumed yet.
+// Persistent expectations are never pending.
+func (m *Mock) Pending() []*Request {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var pending []*Request
+	for _, r := range m.requests {
+		if r.times > 0 && r.calls < r.times {
+			pending = append(pending, r)
+		}
+	}
+	return pending
+}
+
+// Done reports whether all the expectations were consumed.
+func (m *Mock) Done() bool {
+	return len(m.Pending()) == 0
+}
+
+// AssertDone reports a test error for every expectation not consumed yet.
+func (m *Mock) AssertDone(t T) {
+	t.Helper()
+	for _, r := range m.Pending() {
+		t.Errorf("mock: pending expectation %s %s: called %d of %d times", r.method, r.path, r.Calls(), r.times)
+	}
+}
+
+// Clean removes all the registered expectations.
+func (m *Mock) Clean() {
+	m.mtx.Lock()
+	m.requests = nil
+	m.mtx.Unlock()
+}
@@ -0,0 +1,189 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+	"gopkg.in/h2non/gentleman.v2/plugins/transport"
+)
+
+func newClient(m *Mock) *gentleman.Client {
+	cli := gentleman.New()
+	cli.Use(transport.Set(m))
+	return cli
+}
+
+func TestMockReply(t *testing.T) {
+	m := New("http://api")
+	m.Get("/users/:id").
+		MatchHeader("Accept", "application/json").
+		MatchQuery("fields", "name").
+		Reply(200).
+		SetHeader("X-Foo", "bar").
+		JSON(map[string]string{"name": "foo"})
+
+	res, err := newClient(m).Get().URL("http://api/users/123?fields=name").
+		SetHeader("Accept", "application/json").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.Header.Get("X-Foo"), "bar")
+	st.Expect(t, res.Header.Get("Content-Type"), "application/json")
+	st.Expect(t, res.String(), `{"name":"foo"}`)
+	st.Expect(t, m.Done(), true)
+}
+
+func TestMockNoMatch(t *testing.T) {
+	m := New("http://api/v1")
+	m.Get("/users/:id").Reply(200)
+	m.Post("/users").MatchJSON(`{"name": "foo", "tags": ["a"]}`).Reply(201)
+	cli := newClient(m)
+
+	cases := []struct {
+		method, url, body string
+	}{
+		{"GET", "http://api/users/1", ""},
+		{"GET", "https://api/v1/users/1", ""},
+		{"GET", "http://other/v1/users/1", ""},
+		{"GET", "http://api/v1/users/1/posts", ""},
+		{"PUT", "http://api/v1/users/1", ""},
+		{"POST", "http://api/v1/users", `{"name":"bar"}`},
+		{"POST", "http://api/v1/users", `invalid`},
+	}
+	for _, test := range cases {
+		_, err := cli.Request().Method(test.method).URL(test.url).BodyString(test.body).Send()
+		st.Expect(t, errors.Is(err, ErrNoMatch), true)
+	}
+	st.Expect(t, len(m.Pending()), 2)
+
+	res, err := cli.Post().URL("http://api/v1/users").JSON(map[string]interface{}{"tags": []string{"a"}, "name": "foo"}).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 201)
+
+	res, err = cli.Get().URL("http://api/v1/users/1").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, m.Done(), true)
+}
+
+func TestMockPathRegexp(t *testing.T) {
+	m := New("http://api")
+	m.Get(`/users/\d+`).Persist().Reply(200)
+	cli := newClient(m)
+
+	_, err := cli.Get().URL("http://api/users/123").Send()
+	st.Expect(t, err, nil)
+	_, err = cli.Get().URL("http://api/users/foo").Send()
+	st.Expect(t, errors.Is(err, ErrNoMatch), true)
+}
+
+func TestMockTimes(t *testing.T) {
+	m := New("http://api")
+	first := m.Get("/foo").Times(2)
+	first.Reply(200).BodyString("first")
+	persist := m.Get("/foo").Persist()
+	persist.Reply(200).BodyString("persist")
+	cli := newClient(m)
+
+	for _, body := range []string{"first", "first", "persist", "persist", "persist"} {
+		res, err := cli.Get().URL("http://api/foo").Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), body)
+	}
+	st.Expect(t, first.Calls(), 2)
+	st.Expect(t, persist.Calls(), 3)
+	st.Expect(t, m.Done(), true)
+}
+
+func TestMockReplyError(t *testing.T) {
+	m := New("http://api")
+	m.Get("/foo").ReplyError(errors.New("connection refused"))
+
+	_, err := newClient(m).Get().URL("http://api/foo").Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, m.Done(), true)
+}
+
+func TestMockDelay(t *testing.T) {
+	m := New("http://api")
+	m.Get("/foo").Reply(204).Delay(50 * time.Millisecond)
+	m.Get("/bar").Reply(204).Delay(time.Minute)
+	cli := newClient(m)
+
+	start := time.Now()
+	res, err := cli.Get().URL("http://api/foo").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 204)
+	st.Expect(t, time.Since(start) >= 50*time.Millisecond, true)
+
+	start = time.Now()
+	_, err = cli.Get().URL("http://api/bar").Use(timeout.Request(10 * time.Millisecond)).Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, time.Since(start) < time.Minute, true)
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockAssertDone(t *testing.T) {
+	m := New("http://api")
+	m.Get("/foo").Reply(200)
+	m.Post("/bar").Times(2).Reply(201)
+	m.Get("/baz").Persist().Reply(200)
+
+	_, err := newClient(m).Post().URL("http://api/bar").Send()
+	st.Expect(t, err, nil)
+
+	ft := &fakeT{}
+	m.AssertDone(ft)
+	st.Expect(t, ft.errors, []string{
+		"mock: pending expectation GET /foo: called 0 of 1 times",
+		"mock: pending expectation POST /bar: called 1 of 2 times",
+	})
+
+	m.Clean()
+	st.Expect(t, m.Done(), true)
+}
+
+func TestMockConcurrency(t *testing.T) {
+	m := New("http://api")
+	req := m.Get("/foo").Persist()
+	req.Reply(200)
+	cli := newClient(m)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cli.Get().URL("http://api/foo").Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.StatusCode, 200)
+		}()
+	}
+	wg.Wait()
+	st.Expect(t, req.Calls(), 10)
+}
+
+func TestMockRoundTripper(t *testing.T) {
+	m := New("http://api")
+	m.Get("/foo").Reply(200).BodyString("foo")
+
+	res, err := (&http.Client{Transport: m}).Get("http://api/foo")
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.Status, "200 OK")
+}
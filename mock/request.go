@@ -0,0 +1,172 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Matcher represents the function interface implemented by custom request matchers.
+// The request body is given already read, since it can only be consumed once.
+type Matcher func(req *http.Request, body []byte) bool
+
+// params matches the ":name" segments of path patterns.
+var params = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+// Request represents a request expectation of a mock.
+type Request struct {
+	mock     *Mock
+	method   string
+	path     string
+	pattern  *regexp.Regexp
+	matchers []Matcher
+	times    int
+	calls    int
+	response *Response
+}
+
+func newRequest(m *Mock, method, path string) *Request {
+	pattern := params.ReplaceAllString(path, `[^/]+`)
+	prefix := regexp.QuoteMeta(strings.TrimSuffix(m.base.Path, "/"))
+	return &Request{
+		mock:    m,
+		method:  method,
+		path:    path,
+		pattern: regexp.MustCompile("^" + prefix + "(?:" + pattern + ")$"),
+		times:   1,
+	}
+}
+
+// Match adds a custom matcher function to the expectation.
+func (r *Request) Match(matchers ...Matcher) *Request {
+	r.matchers = append(r.matchers, matchers...)
+	return r
+}
+
+// MatchHeader matches requests with the given header field value.
+func (r *Request) MatchHeader(key, value string) *Request {
+	return r.Match(func(req *http.Request, _ []byte) bool {
+		for _, v := range req.Header.Values(key) {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchQuery matches requests with the given URL query param value.
+func (r *Request) MatchQuery(key, value string) *Request {
+	return r.Match(func(req *http.Request, _ []byte) bool {
+		for _, v := range req.URL.Query()[key] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchJSON matches requests whose JSON body is semantically equal to the given data,
+// which can be a string, []byte or any JSON serializable value.
+func (r *Request) MatchJSON(data interface{}) *Request {
+	var expected interface{}
+	switch v := data.(type) {
+	case string:
+		json.Unmarshal([]byte(v), &expected)
+	case []byte:
+		json.Unmarshal(v, &expected)
+	default:
+		buf, _ := json.Marshal(v)
+		json.Unmarshal(buf, &expected)
+	}
+
+	return r.Match(func(_ *http.Request, body []byte) bool {
+		var actual interface{}
+		if err := json.Unmarshal(body, &actual); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(actual, expected)
+	})
+}
+
+// Times defines the number of times the expectation replies. Defaults to 1.
+func (r *Request) Times(times int) *Request {
+	if times > 0 {
+		r.times = times
+	}
+	return r
+}
+
+// Persist makes the expectation reply indefinitely.
+// Persistent expectations are never reported as pending.
+func (r *Request) Persist() *Request {
+	r.times = 0
+	return r
+}
+
+// Calls returns the number of requests replied by the expectation.
+func (r *Request) Calls() int {
+	r.mock.mtx.Lock()
+	defer r.mock.mtx.Unlock()
+	return r.calls
+}
+
+// Reply defines the response status code of the expectation,
+// returning the response for further definition.
+func (r *Request) Reply(status int) *Response {
+	r.response = &Response{status: status, header: make(http.Header)}
+	return r.response
+}
+
+// ReplyError makes the expectation fail with the given error.
+func (r *Request) ReplyError(err error) *Response {
+	r.response = &Response{err: err, header: make(http.Header)}
+	return r.response
+}
+
+// available reports whether the expectation can reply to more requests.
+func (r *Request) available() bool {
+	return r.times <= 0 || r.calls < r.times
+}
+
+// matches reports whether the given request satisfies the expectation.
+func (r *Request) matches(req *http.Request, body []byte) bool {
+	if req.Method != r.method {
+		return false
+	}
+	base := r.mock.base
+	if base.Scheme != "" && req.URL.Scheme != base.Scheme {
+		return false
+	}
+	if base.Host != "" && req.URL.Host != base.Host {
+		return false
+	}
+	if !r.pattern.MatchString(req.URL.Path) {
+		return false
+	}
+	for _, match := range r.matchers {
+		if !match(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+// readBody reads and closes the request body, as transports must do.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	buf, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	return buf, nil
+}
@@ -0,0 +1,88 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Response represents the reply of a request expectation.
+type Response struct {
+	status int
+	header http.Header
+	body   []byte
+	delay  time.Duration
+	err    error
+}
+
+// SetHeader sets a new response header field.
+func (r *Response) SetHeader(key, value string) *Response {
+	r.header.Set(key, value)
+	return r
+}
+
+// Type sets the response Content-Type header field.
+func (r *Response) Type(kind string) *Response {
+	return r.SetHeader("Content-Type", kind)
+}
+
+// BodyString defines the response body as string.
+func (r *Response) BodyString(body string) *Response {
+	r.body = []byte(body)
+	return r
+}
+
+// Bytes defines the response body as raw bytes.
+func (r *Response) Bytes(body []byte) *Response {
+	r.body = body
+	return r
+}
+
+// JSON defines the response body serializing the given data as JSON,
+// setting the Content-Type header if not defined yet.
+// Strings and []byte are used as raw JSON.
+func (r *Response) JSON(data interface{}) *Response {
+	switch v := data.(type) {
+	case string:
+		r.body = []byte(v)
+	case []byte:
+		r.body = v
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		r.body = buf
+	}
+	if r.header.Get("Content-Type") == "" {
+		r.header.Set("Content-Type", "application/json")
+	}
+	return r
+}
+
+// Delay defines the time to wait before replying.
+// The wait is aborted if the request context is canceled.
+func (r *Response) Delay(delay time.Duration) *Response {
+	r.delay = delay
+	return r
+}
+
+// build creates the HTTP response for the given request.
+func (r *Response) build(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strings.TrimSpace(fmt.Sprintf("%d %s", r.status, http.StatusText(r.status))),
+		StatusCode:    r.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
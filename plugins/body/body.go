@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"io"
 	"io/ioutil"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	c "gopkg.in/h2non/gentleman.v2/context"
 	p "gopkg.in/h2non/gentleman.v2/plugin"
@@ -19,6 +22,9 @@ func String(data string) p.Plugin {
 		ctx.Request.Method = getMethod(ctx)
 		ctx.Request.Body = utils.StringReader(data)
 		ctx.Request.ContentLength = int64(bytes.NewBufferString(data).Len())
+		ctx.Request.GetBody = func() (io.ReadCloser, error) {
+			return utils.StringReader(data), nil
+		}
 		h.Next(ctx)
 	})
 }
@@ -42,8 +48,7 @@ func JSON(data interface{}) p.Plugin {
 		}
 
 		ctx.Request.Method = getMethod(ctx)
-		ctx.Request.Body = ioutil.NopCloser(buf)
-		ctx.Request.ContentLength = int64(buf.Len())
+		setBody(ctx, buf.Bytes())
 		ctx.Request.Header.Set("Content-Type", "application/json")
 
 		h.Next(ctx)
@@ -69,8 +74,7 @@ func XML(data interface{}) p.Plugin {
 		}
 
 		ctx.Request.Method = getMethod(ctx)
-		ctx.Request.Body = ioutil.NopCloser(buf)
-		ctx.Request.ContentLength = int64(buf.Len())
+		setBody(ctx, buf.Bytes())
 		ctx.Request.Header.Set("Content-Type", "application/xml")
 
 		h.Next(ctx)
@@ -79,32 +83,75 @@ func XML(data interface{}) p.Plugin {
 
 // Reader defines a io.Reader stream as request body.
 // Content-Type header won't be defined automatically, you have to declare it manually.
+//
+// Buffers, bytes.Reader and strings.Reader bodies are re-created on every dispatch,
+// while any other stream can only be sent once: further dispatches fail.
 func Reader(body io.Reader) p.Plugin {
-	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
-		rc, ok := body.(io.ReadCloser)
-		if !ok && body != nil {
-			rc = ioutil.NopCloser(body)
-		}
+	var once sync.Once
+	var sent int32
+	var length int64
+	var getBody func() (io.ReadCloser, error)
 
-		req := ctx.Request
-		if body != nil {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		// Snapshot the re-readable bodies only once, before being consumed
+		once.Do(func() {
 			switch v := body.(type) {
 			case *bytes.Buffer:
-				req.ContentLength = int64(v.Len())
+				length = int64(v.Len())
+				buf := v.Bytes()
+				getBody = func() (io.ReadCloser, error) {
+					return ioutil.NopCloser(bytes.NewReader(buf)), nil
+				}
 			case *bytes.Reader:
-				req.ContentLength = int64(v.Len())
+				length = int64(v.Len())
+				snapshot := *v
+				getBody = func() (io.ReadCloser, error) {
+					r := snapshot
+					return ioutil.NopCloser(&r), nil
+				}
 			case *strings.Reader:
-				req.ContentLength = int64(v.Len())
+				length = int64(v.Len())
+				snapshot := *v
+				getBody = func() (io.ReadCloser, error) {
+					r := snapshot
+					return ioutil.NopCloser(&r), nil
+				}
 			}
-		}
+		})
 
-		req.Body = rc
+		req := ctx.Request
+		req.GetBody = getBody
+		if getBody != nil {
+			req.Body, _ = getBody()
+			req.ContentLength = length
+		} else {
+			// Never send again an already consumed stream as an empty body
+			if body != nil && !atomic.CompareAndSwapInt32(&sent, 0, 1) {
+				h.Error(ctx, errors.New("gentleman: request body stream was already sent"))
+				return
+			}
+			rc, ok := body.(io.ReadCloser)
+			if !ok && body != nil {
+				rc = ioutil.NopCloser(body)
+			}
+			req.Body = rc
+		}
 		ctx.Request.Method = getMethod(ctx)
 
 		h.Next(ctx)
 	})
 }
 
+// setBody defines the given buffer as request body, allowing
+// the transport to re-create it via http.Request.GetBody.
+func setBody(ctx *c.Context, buf []byte) {
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	ctx.Request.ContentLength = int64(len(buf))
+	ctx.Request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+}
+
 func getMethod(ctx *c.Context) string {
 	method := ctx.Request.Method
 	if method == "" {
@@ -3,6 +3,7 @@ package body
 import (
 	"bytes"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/nbio/st"
@@ -123,6 +124,60 @@ func TestBodyReader(t *testing.T) {
 	st.Expect(t, string(buf), "foo bar")
 }
 
+func TestBodyGetBody(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+
+	Reader(bytes.NewReader([]byte("foo bar"))).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+	ioutil.ReadAll(ctx.Request.Body)
+
+	body, err := ctx.Request.GetBody()
+	st.Expect(t, err, nil)
+	buf, _ := ioutil.ReadAll(body)
+	st.Expect(t, string(buf), "foo bar")
+
+	JSON(`{"foo":"bar"}`).Exec("request", ctx, fn.fn)
+	body, err = ctx.Request.GetBody()
+	st.Expect(t, err, nil)
+	buf, _ = ioutil.ReadAll(body)
+	st.Expect(t, string(buf), `{"foo":"bar"}`)
+}
+
+func TestBodyReaderReusable(t *testing.T) {
+	plugin := Reader(bytes.NewBufferString("foo bar"))
+
+	for i := 0; i < 2; i++ {
+		ctx := context.New()
+		ctx.Request.Method = "POST"
+		fn := newHandler()
+		plugin.Exec("request", ctx, fn.fn)
+		st.Expect(t, fn.called, true)
+
+		buf, err := ioutil.ReadAll(ctx.Request.Body)
+		st.Expect(t, err, nil)
+		st.Expect(t, int(ctx.Request.ContentLength), 7)
+		st.Expect(t, string(buf), "foo bar")
+	}
+}
+
+func TestBodyReaderStreamSentOnce(t *testing.T) {
+	plugin := Reader(ioutil.NopCloser(strings.NewReader("foo bar")))
+
+	ctx := context.New()
+	fn := newHandler()
+	plugin.Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+	buf, _ := ioutil.ReadAll(ctx.Request.Body)
+	st.Expect(t, string(buf), "foo bar")
+
+	// The consumed stream cannot be sent again
+	ctx = context.New()
+	fn = newHandler()
+	plugin.Exec("request", ctx, fn.fn)
+	st.Expect(t, ctx.Error.Error(), "gentleman: request body stream was already sent")
+}
+
 func TestBodyReaderContextDataSharing(t *testing.T) {
 	ctx := context.New()
 	ctx.Request.Method = "POST"
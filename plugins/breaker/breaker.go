@@ -0,0 +1,348 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+var (
+	// ErrOpen is the error matched by OpenError via errors.Is.
+	ErrOpen = errors.New("gentleman: circuit breaker is open")
+
+	// Threshold defines the default number of consecutive failures
+	// required to open the circuit.
+	Threshold = 5
+
+	// Cooldown defines the default amount of time the circuit remains open
+	// before allowing trial requests.
+	Cooldown = 30 * time.Second
+)
+
+// State represents the circuit breaker state.
+type State int
+
+const (
+	// Closed state lets requests flow through.
+	Closed State = iota
+
+	// Open state fails requests fast without dialing.
+	Open
+
+	// HalfOpen state lets a limited number of trial requests flow through.
+	HalfOpen
+)
+
+// String returns the state name.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// OpenError is the error reported when a request is rejected
+// because its circuit is open.
+type OpenError struct {
+	// Key identifies the rejected circuit.
+	Key string
+
+	// Until stores the time when the circuit will go half-open.
+	Until time.Time
+}
+
+// Error implements the error interface.
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrOpen.Error(), e.Key)
+}
+
+// Is makes errors.Is(err, ErrOpen) report true.
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// KeyFunc returns the circuit key for the given request context.
+type KeyFunc func(*c.Context) string
+
+// Host is the default KeyFunc, which tracks circuits per URL host.
+func Host(ctx *c.Context) string {
+	return ctx.Request.URL.Host
+}
+
+// FailureFunc reports whether the given request context
+// must be considered as a failure.
+type FailureFunc func(*c.Context) bool
+
+// ServerFailure is the default FailureFunc, which considers
+// as failures network errors and 5xx server responses.
+func ServerFailure(ctx *c.Context) bool {
+	return ctx.Error != nil || ctx.Response.StatusCode >= 500
+}
+
+// StateChangeFunc is the callback function type notified
+// on circuit state transitions.
+type StateChangeFunc func(key string, from, to State)
+
+// Options stores the circuit breaker options.
+type Options struct {
+	// Threshold defines the number of consecutive failures required to open the circuit.
+	// Defaults to Threshold.
+	Threshold int
+
+	// Cooldown defines the amount of time the circuit remains open before going half-open.
+	// Defaults to Cooldown.
+	Cooldown time.Duration
+
+	// HalfOpenRequests defines the maximum number of concurrent trial requests
+	// allowed while half-open. Defaults to 1.
+	HalfOpenRequests int
+
+	// Key defines the function used to compute the circuit key. Defaults to Host.
+	Key KeyFunc
+
+	// Failure defines the function used to evaluate failures. Defaults to ServerFailure.
+	Failure FailureFunc
+
+	// OnStateChange is an optional callback notified on state transitions.
+	OnStateChange StateChangeFunc
+}
+
+// circuit stores the state of a single circuit.
+type circuit struct {
+	state    State
+	failures int
+	trials   int
+	openedAt time.Time
+}
+
+// token stores the circuit admission of an in-flight request.
+type token struct {
+	key   string
+	trial bool
+}
+
+// Breaker implements a circuit breaker plugin with per key circuits.
+//
+// Circuits are evaluated in the "before dial" phase, once the "request" phase
+// middleware defined the final URL, so rejected requests never reach the network.
+// Outcomes are recorded in the "response" and "error" phases. Errors not caused
+// by the HTTP transaction, such as errors reported by other "before dial" middleware,
+// are not recorded as outcomes.
+type Breaker struct {
+	// Breaker implements the plugin interface.
+	*p.Layer
+
+	opts     Options
+	mtx      sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// New creates a new circuit breaker plugin based on the given options.
+func New(opts Options) *Breaker {
+	if opts.Threshold <= 0 {
+		opts.Threshold = Threshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = Cooldown
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	if opts.Key == nil {
+		opts.Key = Host
+	}
+	if opts.Failure == nil {
+		opts.Failure = ServerFailure
+	}
+
+	b := &Breaker{Layer: p.New(), opts: opts, circuits: make(map[string]*circuit), now: time.Now}
+	b.SetHandlers(p.Handlers{
+		"before dial": b.admit,
+		"response":    b.record,
+		"error":       b.record,
+		"stop":        b.release,
+	})
+	return b
+}
+
+// State returns the current state of the circuit identified by the given key.
+func (b *Breaker) State(key string) State {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if cb, ok := b.circuits[key]; ok {
+		return cb.state
+	}
+	return Closed
+}
+
+// Reset resets the circuit identified by the given key to the closed state.
+func (b *Breaker) Reset(key string) {
+	b.mtx.Lock()
+	cb, ok := b.circuits[key]
+	if !ok {
+		b.mtx.Unlock()
+		return
+	}
+	from := cb.state
+	*cb = circuit{}
+	b.mtx.Unlock()
+	b.notify(key, from, Closed)
+}
+
+// admit verifies if the outgoing request can be dialed, failing fast otherwise.
+func (b *Breaker) admit(ctx *c.Context, h c.Handler) {
+	// Requests built with no network dialing, such as by Request.Build, are not evaluated
+	if ctx.Get("$dryrun") == true {
+		h.Next(ctx)
+		return
+	}
+
+	key := b.opts.Key(ctx)
+
+	b.mtx.Lock()
+	cb, ok := b.circuits[key]
+	if !ok {
+		cb = &circuit{}
+		b.circuits[key] = cb
+	}
+
+	from := cb.state
+	if cb.state == Open && b.now().Sub(cb.openedAt) >= b.opts.Cooldown {
+		cb.state = HalfOpen
+		cb.trials = 0
+	}
+
+	var err error
+	trial := false
+	switch cb.state {
+	case Open:
+		err = &OpenError{Key: key, Until: cb.openedAt.Add(b.opts.Cooldown)}
+	case HalfOpen:
+		if cb.trials >= b.opts.HalfOpenRequests {
+			err = &OpenError{Key: key, Until: b.now()}
+		} else {
+			cb.trials++
+			trial = true
+		}
+	}
+	to := cb.state
+	b.mtx.Unlock()
+
+	b.notify(key, from, to)
+	if err != nil {
+		h.Error(ctx, err)
+		return
+	}
+
+	ctx.Set(b, &token{key: key, trial: trial})
+	h.Next(ctx)
+}
+
+// record records the outcome of an admitted request.
+func (b *Breaker) record(ctx *c.Context, h c.Handler) {
+	tk := b.take(ctx)
+	if tk == nil {
+		h.Next(ctx)
+		return
+	}
+
+	// Errors reported by the middleware never reached the upstream
+	if ctx.Error != nil && !transportError(ctx.Error) {
+		b.free(tk)
+		h.Next(ctx)
+		return
+	}
+
+	failed := b.opts.Failure(ctx)
+
+	b.mtx.Lock()
+	cb := b.circuits[tk.key]
+	from := cb.state
+	switch {
+	case cb.state == HalfOpen && tk.trial:
+		cb.trials--
+		if failed {
+			b.open(cb)
+		} else {
+			*cb = circuit{}
+		}
+	case cb.state == Closed:
+		if !failed {
+			cb.failures = 0
+			break
+		}
+		cb.failures++
+		if cb.failures >= b.opts.Threshold {
+			b.open(cb)
+		}
+	}
+	to := cb.state
+	b.mtx.Unlock()
+
+	b.notify(tk.key, from, to)
+	h.Next(ctx)
+}
+
+// release frees the trial slot of stopped requests without recording its outcome.
+func (b *Breaker) release(ctx *c.Context, h c.Handler) {
+	if tk := b.take(ctx); tk != nil {
+		b.free(tk)
+	}
+	h.Next(ctx)
+}
+
+// free frees the trial slot of the given admission token, if any.
+func (b *Breaker) free(tk *token) {
+	if !tk.trial {
+		return
+	}
+	b.mtx.Lock()
+	if cb := b.circuits[tk.key]; cb.state == HalfOpen {
+		cb.trials--
+	}
+	b.mtx.Unlock()
+}
+
+// transportError reports whether the given error was returned by the http.Client,
+// which always reports errors as *url.Error.
+func transportError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// take retrieves and removes the admission token from the given context.
+func (b *Breaker) take(ctx *c.Context) *token {
+	tk, ok := ctx.Get(b).(*token)
+	if !ok {
+		return nil
+	}
+	ctx.Delete(b)
+	return tk
+}
+
+// open transitions the given circuit to the open state.
+// Must be called with the lock held.
+func (b *Breaker) open(cb *circuit) {
+	cb.state = Open
+	cb.failures = 0
+	cb.trials = 0
+	cb.openedAt = b.now()
+}
+
+// notify calls the state change callback, if present, on state transitions.
+func (b *Breaker) notify(key string, from, to State) {
+	if from != to && b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(key, from, to)
+	}
+}
@@ -0,0 +1,172 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestBreakerOpens(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+
+	var transitions []string
+	cb := New(Options{Threshold: 2, OnStateChange: func(key string, from, to State) {
+		transitions = append(transitions, from.String()+">"+to.String())
+	}})
+
+	cli := gentleman.New()
+	cli.Use(cb)
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Request().URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 500)
+	}
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, errors.Is(err, ErrOpen), true)
+
+	var openErr *OpenError
+	st.Expect(t, errors.As(err, &openErr), true)
+	st.Expect(t, openErr.Key, ts.Listener.Addr().String())
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+	st.Expect(t, cb.State(openErr.Key), Open)
+	st.Expect(t, transitions, []string{"closed>open"})
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	var fail int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	var transitions []string
+	cb := New(Options{Threshold: 1, Cooldown: time.Minute, OnStateChange: func(key string, from, to State) {
+		transitions = append(transitions, from.String()+">"+to.String())
+	}})
+	now := time.Now()
+	cb.now = func() time.Time { return now }
+
+	cli := gentleman.New()
+	cli.Use(cb)
+	key := ts.Listener.Addr().String()
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 503)
+	st.Expect(t, cb.State(key), Open)
+
+	// Trial request fails and opens the circuit again
+	now = now.Add(time.Minute)
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 503)
+	st.Expect(t, cb.State(key), Open)
+
+	// Trial request succeeds and closes the circuit
+	now = now.Add(time.Minute)
+	atomic.StoreInt32(&fail, 0)
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, cb.State(key), Closed)
+
+	st.Expect(t, transitions, []string{
+		"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed",
+	})
+}
+
+func TestBreakerNetworkError(t *testing.T) {
+	cb := New(Options{Threshold: 1})
+	cli := gentleman.New()
+	cli.Use(cb)
+
+	_, err := cli.Request().URL("http://127.0.0.1:9123").Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, errors.Is(err, ErrOpen), false)
+	st.Expect(t, cb.State("127.0.0.1:9123"), Open)
+
+	_, err = cli.Request().URL("http://127.0.0.1:9123").Send()
+	st.Expect(t, errors.Is(err, ErrOpen), true)
+
+	cb.Reset("127.0.0.1:9123")
+	st.Expect(t, cb.State("127.0.0.1:9123"), Closed)
+}
+
+func TestBreakerMiddlewareError(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer ts.Close()
+
+	cb := New(Options{Threshold: 1})
+	cli := gentleman.New()
+	cli.Use(cb)
+	cli.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		if ctx.Request.Header.Get("X-Fail") != "" {
+			h.Error(ctx, errors.New("local error"))
+			return
+		}
+		h.Next(ctx)
+	})
+	key := ts.Listener.Addr().String()
+
+	// Local middleware errors never reach the upstream and do not trip the breaker
+	for i := 0; i < 3; i++ {
+		_, err := cli.Request().URL(ts.URL).SetHeader("X-Fail", "1").Send()
+		st.Expect(t, err.Error(), "local error")
+		st.Expect(t, cb.State(key), Closed)
+	}
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestBreakerCustomKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+
+	cb := New(Options{Threshold: 1, Key: func(ctx *context.Context) string {
+		return ctx.Request.URL.Path
+	}})
+	cli := gentleman.New()
+	cli.Use(cb)
+
+	cli.Request().URL(ts.URL + "/foo").Send()
+	st.Expect(t, cb.State("/foo"), Open)
+	st.Expect(t, cb.State("/bar"), Closed)
+
+	res, err := cli.Request().URL(ts.URL + "/bar").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 500)
+}
+
+func TestStateString(t *testing.T) {
+	st.Expect(t, Closed.String(), "closed")
+	st.Expect(t, Open.String(), "open")
+	st.Expect(t, HalfOpen.String(), "half-open")
+	st.Expect(t, State(10).String(), "unknown")
+}
@@ -0,0 +1,247 @@
+package bulkhead
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+var (
+	// ErrQueueFull is the error returned when the maximum number of queued
+	// requests has been reached.
+	ErrQueueFull = errors.New("gentleman: bulkhead queue is full")
+
+	// ErrQueueTimeout is the error returned when a queued request
+	// could not acquire a slot in time.
+	ErrQueueTimeout = errors.New("gentleman: bulkhead queue timeout exceeded")
+)
+
+// KeyFunc returns the compartment key for the given request context.
+type KeyFunc func(*c.Context) string
+
+// Host is the default KeyFunc, which limits concurrency per URL host.
+func Host(ctx *c.Context) string {
+	return ctx.Request.URL.Host
+}
+
+// Options stores the bulkhead options.
+type Options struct {
+	// MaxConcurrent defines the maximum number of in-flight requests per compartment.
+	// Defaults to 10.
+	MaxConcurrent int
+
+	// QueueDepth defines the maximum number of requests waiting for a slot per compartment.
+	// Zero means requests exceeding the limit are rejected right away.
+	QueueDepth int
+
+	// QueueTimeout defines the maximum amount of time a request can wait for a slot.
+	// Zero means no timeout, other than the request cancel context.
+	QueueTimeout time.Duration
+
+	// Key defines the function used to compute the compartment key. Defaults to Host.
+	Key KeyFunc
+
+	// HoldBody makes responses hold the slot until their body is fully read or closed,
+	// so slow body downloads count against the limit. Callers must then always
+	// read or close the response body, otherwise the slot is never released.
+	HoldBody bool
+}
+
+// compartment stores the concurrency state of a single key.
+type compartment struct {
+	slots   chan struct{}
+	waiting int
+}
+
+// slot stores an acquired slot of an in-flight request.
+type slot struct {
+	once sync.Once
+	comp *compartment
+}
+
+// Bulkhead implements a plugin that limits the number of concurrent
+// in-flight requests per host or custom key.
+//
+// A slot is acquired in the "before dial" phase and released once the
+// "response", "error" or "stop" phase finishes.
+// See Options.HoldBody to also account for the response body download.
+type Bulkhead struct {
+	// Bulkhead implements the plugin interface.
+	*p.Layer
+
+	opts         Options
+	mtx          sync.Mutex
+	compartments map[string]*compartment
+}
+
+// New creates a new bulkhead plugin based on the given options.
+func New(opts Options) *Bulkhead {
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = 10
+	}
+	if opts.Key == nil {
+		opts.Key = Host
+	}
+
+	b := &Bulkhead{Layer: p.New(), opts: opts, compartments: make(map[string]*compartment)}
+	b.SetHandlers(p.Handlers{
+		"before dial": b.acquire,
+		"response":    b.release,
+		"error":       b.release,
+		"stop":        b.release,
+	})
+	return b
+}
+
+// InFlight returns the number of in-flight requests for the given key.
+func (b *Bulkhead) InFlight(key string) int {
+	return len(b.compartment(key).slots)
+}
+
+// compartment returns the compartment for the given key, creating it if required.
+func (b *Bulkhead) compartment(key string) *compartment {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	comp, ok := b.compartments[key]
+	if !ok {
+		comp = &compartment{slots: make(chan struct{}, b.opts.MaxConcurrent)}
+		b.compartments[key] = comp
+	}
+	return comp
+}
+
+// acquire waits for an available slot, failing if the queue is full or the wait times out.
+// Requests built with no network dialing, such as by Request.Build, never wait.
+func (b *Bulkhead) acquire(ctx *c.Context, h c.Handler) {
+	if ctx.Get("$dryrun") == true {
+		h.Next(ctx)
+		return
+	}
+
+	comp := b.compartment(b.opts.Key(ctx))
+	if err := b.wait(ctx, comp); err != nil {
+		h.Error(ctx, err)
+		return
+	}
+
+	ctx.Set(b, &slot{comp: comp})
+	h.Next(ctx)
+}
+
+// wait blocks until a slot is acquired in the given compartment.
+func (b *Bulkhead) wait(ctx gocontext.Context, comp *compartment) error {
+	select {
+	case comp.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	b.mtx.Lock()
+	if comp.waiting >= b.opts.QueueDepth {
+		b.mtx.Unlock()
+		return ErrQueueFull
+	}
+	comp.waiting++
+	b.mtx.Unlock()
+
+	defer func() {
+		b.mtx.Lock()
+		comp.waiting--
+		b.mtx.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if b.opts.QueueTimeout > 0 {
+		timer := time.NewTimer(b.opts.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case comp.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return ErrQueueTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the slot acquired by the request, if any, once the
+// current phase finishes and the HTTP transaction is completed.
+func (b *Bulkhead) release(ctx *c.Context, h c.Handler) {
+	s, ok := ctx.Get(b).(*slot)
+	if !ok {
+		h.Next(ctx)
+		return
+	}
+
+	phase := ctx.GetString("$phase")
+	defer func() {
+		// An error phase recovered by the middleware lets the transaction continue
+		if phase == "error" && ctx.Error == nil {
+			return
+		}
+		// Hold the slot until the response body is consumed, if enabled
+		if b.opts.HoldBody && phase == "response" && ctx.Error == nil && hasBody(ctx.Response) {
+			ctx.Response.Body = newBody(ctx.Response.Body, s.release)
+			return
+		}
+		s.release()
+	}()
+
+	h.Next(ctx)
+}
+
+// release frees the slot, only once.
+func (s *slot) release() {
+	s.once.Do(func() { <-s.comp.slots })
+}
+
+// hasBody reports whether the given response has a body to be consumed.
+func hasBody(res *http.Response) bool {
+	return res != nil && res.Body != nil && res.Body != http.NoBody && res.ContentLength != 0
+}
+
+// body wraps a response body calling release once it is fully read or closed.
+type body struct {
+	io.ReadCloser
+	release func()
+}
+
+// newBody wraps the given response body, preserving the io.Writer
+// implemented by the body of protocol upgrade responses.
+func newBody(rc io.ReadCloser, release func()) io.ReadCloser {
+	b := &body{ReadCloser: rc, release: release}
+	if w, ok := rc.(io.Writer); ok {
+		return &writerBody{body: b, Writer: w}
+	}
+	return b
+}
+
+// Read implements the io.Reader interface.
+func (b *body) Read(buf []byte) (int, error) {
+	n, err := b.ReadCloser.Read(buf)
+	if err != nil {
+		b.release()
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (b *body) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// writerBody is a body which also implements the io.Writer interface.
+type writerBody struct {
+	*body
+	io.Writer
+}
@@ -0,0 +1,207 @@
+package bulkhead
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestBulkheadConcurrency(t *testing.T) {
+	var current, max int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+	}))
+	defer ts.Close()
+
+	bh := New(Options{MaxConcurrent: 2, QueueDepth: 10})
+	cli := gentleman.New()
+	cli.Use(bh)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cli.Request().URL(ts.URL).Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.StatusCode, 200)
+		}()
+	}
+	wg.Wait()
+
+	st.Expect(t, atomic.LoadInt32(&max), int32(2))
+	st.Expect(t, bh.InFlight(ts.Listener.Addr().String()), 0)
+}
+
+func TestBulkheadBuild(t *testing.T) {
+	bh := New(Options{MaxConcurrent: 2})
+	cli := gentleman.New()
+	cli.Use(bh)
+
+	// Requests built with no network dialing take no slot
+	for i := 0; i < 5; i++ {
+		_, err := cli.Request().URL("http://localhost:8080").Build()
+		st.Expect(t, err, nil)
+	}
+	st.Expect(t, bh.InFlight("localhost:8080"), 0)
+}
+
+func TestBulkheadQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+
+	bh := New(Options{MaxConcurrent: 1})
+	cli := gentleman.New()
+	cli.Use(bh)
+
+	done := make(chan error)
+	go func() {
+		_, err := cli.Request().URL(ts.URL).Send()
+		done <- err
+	}()
+
+	key := ts.Listener.Addr().String()
+	for bh.InFlight(key) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, ErrQueueFull)
+
+	close(release)
+	st.Expect(t, <-done, nil)
+	st.Expect(t, bh.InFlight(key), 0)
+}
+
+func TestBulkheadReleaseOnResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+	}))
+	defer ts.Close()
+
+	bh := New(Options{MaxConcurrent: 1})
+	cli := gentleman.New()
+	cli.Use(bh)
+	key := ts.Listener.Addr().String()
+
+	// The slot is released once the response phase finishes, even if the body is never read
+	for i := 0; i < 3; i++ {
+		res, err := cli.Request().URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 200)
+		st.Expect(t, bh.InFlight(key), 0)
+	}
+}
+
+func TestBulkheadHoldUntilBodyRead(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("bar"))
+	}))
+	defer ts.Close()
+
+	bh := New(Options{MaxConcurrent: 1, HoldBody: true})
+	cli := gentleman.New()
+	cli.Use(bh)
+	key := ts.Listener.Addr().String()
+
+	// The slot is held while the body is being downloaded
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, bh.InFlight(key), 1)
+
+	_, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, ErrQueueFull)
+
+	close(release)
+	st.Expect(t, res.String(), "foobar")
+	st.Expect(t, bh.InFlight(key), 0)
+
+	// Closing the body without reading it also releases the slot
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, bh.InFlight(key), 1)
+	st.Expect(t, res.Close(), nil)
+	st.Expect(t, bh.InFlight(key), 0)
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+
+	bh := New(Options{MaxConcurrent: 1, QueueDepth: 1, QueueTimeout: 20 * time.Millisecond})
+	cli := gentleman.New()
+	cli.Use(bh)
+
+	done := make(chan error)
+	go func() {
+		_, err := cli.Request().URL(ts.URL).Send()
+		done <- err
+	}()
+
+	key := ts.Listener.Addr().String()
+	for bh.InFlight(key) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, errors.Is(err, ErrQueueTimeout), true)
+
+	close(release)
+	st.Expect(t, <-done, nil)
+}
+
+func TestBulkheadReleaseOnError(t *testing.T) {
+	bh := New(Options{MaxConcurrent: 1})
+	cli := gentleman.New()
+	cli.Use(bh)
+
+	for i := 0; i < 3; i++ {
+		_, err := cli.Request().URL("http://127.0.0.1:9123").Send()
+		st.Reject(t, err, nil)
+		st.Expect(t, err == ErrQueueFull, false)
+	}
+	st.Expect(t, bh.InFlight("127.0.0.1:9123"), 0)
+}
+
+func TestBulkheadReleaseOnStop(t *testing.T) {
+	bh := New(Options{MaxConcurrent: 1})
+	cli := gentleman.New()
+	cli.Use(bh)
+	cli.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		ctx.Response.StatusCode = 200
+		h.Next(ctx)
+	})
+
+	for i := 0; i < 3; i++ {
+		res, err := cli.Request().URL("http://127.0.0.1:9123").Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 200)
+	}
+	st.Expect(t, bh.InFlight("127.0.0.1:9123"), 0)
+}
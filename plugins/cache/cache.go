@@ -0,0 +1,361 @@
+package cache
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
+)
+
+var (
+	// Capacity defines the default number of entries stored by the in-memory storage.
+	Capacity = 1000
+
+	// MaxBodySize defines the default maximum response body size in bytes that can be stored.
+	MaxBodySize int64 = 10 * 1024 * 1024
+)
+
+// Status represents how a response was resolved by the cache.
+type Status string
+
+const (
+	// Miss status means the response was fetched from the network.
+	Miss Status = "MISS"
+
+	// Hit status means the response was served from the cache without dialing.
+	Hit Status = "HIT"
+
+	// Revalidated status means the stored response was confirmed
+	// by the server via a conditional request.
+	Revalidated Status = "REVALIDATED"
+
+	// Stale status means a stale stored response was served
+	// because the network call failed or the server replied with a 5xx status.
+	Stale Status = "STALE"
+)
+
+// statusKey stores the cache status in the request context store.
+const statusKey = "$cache"
+
+// StatusOf returns the cache status of the given request context,
+// or an empty string if the request was not handled by the cache.
+func StatusOf(ctx *c.Context) Status {
+	status, _ := ctx.Get(statusKey).(Status)
+	return status
+}
+
+// Options stores the cache options.
+type Options struct {
+	// Storage defines the storage backend. Defaults to an in-memory LRU storage.
+	Storage Storage
+
+	// Shared defines if the cache behaves as a shared cache, where private
+	// responses are not stored and s-maxage takes precedence over max-age.
+	Shared bool
+
+	// MaxBodySize defines the maximum response body size in bytes that can be stored.
+	// Defaults to MaxBodySize.
+	MaxBodySize int64
+
+	// StaleIfError enables answering network errors and 5xx responses with
+	// the last stored response, even if stale. Otherwise, stale responses are
+	// only served on error if allowed by the stale-if-error directive (RFC 5861).
+	StaleIfError bool
+
+	// MaxStale defines how long a response can be stale to be served on error
+	// when StaleIfError is enabled. Zero means no limit.
+	MaxStale time.Duration
+}
+
+// transaction stores the cache state of a single request.
+type transaction struct {
+	key         string
+	entry       *Entry
+	validate    bool
+	invalidate  bool
+	served      bool
+	requestTime time.Time
+}
+
+// Cache implements an HTTP caching plugin as defined in RFC 9111.
+//
+// Fresh stored responses are served in the "before dial" phase, intercepting
+// the request. Stale responses are revalidated via conditional requests, and
+// cacheable responses are stored in the "after dial" phase.
+// Only GET responses are cached, while unsafe methods invalidate the stored
+// response for the target URL.
+//
+// Stale responses can also be served in the "error" phase when the network
+// call fails, or in the "after dial" phase when the server replies with a 5xx
+// status, flagging the gentleman.Response as Stale.
+type Cache struct {
+	// Cache implements the plugin interface.
+	*p.Layer
+
+	opts Options
+	now  func() time.Time
+}
+
+// New creates a new cache plugin based on the given options.
+func New(opts Options) *Cache {
+	if opts.Storage == nil {
+		opts.Storage = NewMemory(Capacity)
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = MaxBodySize
+	}
+
+	cache := &Cache{Layer: p.New(), opts: opts, now: time.Now}
+	cache.SetHandlers(p.Handlers{
+		"before dial": cache.lookup,
+		"after dial":  cache.store,
+		"error":       cache.recover,
+	})
+	return cache
+}
+
+// Storage returns the storage backend used by the cache.
+func (cache *Cache) Storage() Storage {
+	return cache.opts.Storage
+}
+
+// lookup serves fresh stored responses or prepares stale ones for revalidation.
+func (cache *Cache) lookup(ctx *c.Context, h c.Handler) {
+	req := ctx.Request
+	tx := &transaction{key: key(req), requestTime: cache.now()}
+
+	if !safe(req.Method) {
+		tx.invalidate = true
+		ctx.Set(cache, tx)
+		h.Next(ctx)
+		return
+	}
+	if req.Method != "GET" {
+		h.Next(ctx)
+		return
+	}
+
+	ctx.Set(cache, tx)
+	ctx.Set(statusKey, Miss)
+
+	entry, ok := cache.opts.Storage.Get(tx.key)
+	if ok && !entry.matches(req) {
+		entry, ok = nil, false
+	}
+
+	if ok {
+		current := age(entry, tx.requestTime)
+		if fresh(req, entry, current, cache.opts.Shared) {
+			cache.serve(ctx, entry, current, Hit)
+			h.Next(ctx)
+			return
+		}
+	}
+
+	// Only stored responses can be served: reply with a gateway timeout
+	if parseControl(req.Header).has("only-if-cached") {
+		utils.ReplyWithStatus(ctx.Response, http.StatusGatewayTimeout)
+		h.Next(ctx)
+		return
+	}
+
+	if ok {
+		tx.entry = entry
+		tx.validate = validate(req, entry)
+	}
+
+	h.Next(ctx)
+}
+
+// store updates the storage based on the received response.
+func (cache *Cache) store(ctx *c.Context, h c.Handler) {
+	tx, ok := ctx.Get(cache).(*transaction)
+	if !ok {
+		h.Next(ctx)
+		return
+	}
+
+	req, res := ctx.Request, ctx.Response
+	switch {
+	case tx.served:
+		// The stored response was already served in the error phase
+
+	case tx.invalidate:
+		if res.StatusCode < 400 {
+			cache.opts.Storage.Delete(tx.key)
+		}
+
+	case res.StatusCode >= 500 && cache.stale(tx):
+		res.Body.Close()
+		cache.serve(ctx, tx.entry, age(tx.entry, cache.now()), Stale)
+
+	case tx.validate && res.StatusCode == http.StatusNotModified:
+		entry := cache.update(tx, res)
+		res.Body.Close()
+		ctx.Response = entry.response(req)
+		ctx.Set(statusKey, Revalidated)
+
+	case res.StatusCode == http.StatusNotModified:
+		// Replies to the caller's own conditional requests are returned as is,
+		// only refreshing the stored response if it has the same validator
+		if tx.entry != nil && res.Header.Get("ETag") != "" && res.Header.Get("ETag") == tx.entry.Header.Get("ETag") {
+			cache.update(tx, res)
+		}
+
+	case storable(req, res, cache.opts.Shared):
+		if entry := cache.entry(tx, req, res); entry != nil {
+			cache.opts.Storage.Set(tx.key, entry)
+		}
+	}
+
+	h.Next(ctx)
+}
+
+// recover answers network errors with the stored response, if allowed.
+func (cache *Cache) recover(ctx *c.Context, h c.Handler) {
+	tx, ok := ctx.Get(cache).(*transaction)
+	if !ok || !cache.stale(tx) {
+		h.Next(ctx)
+		return
+	}
+
+	tx.served = true
+	ctx.Error = nil
+	cache.serve(ctx, tx.entry, age(tx.entry, cache.now()), Stale)
+	h.Next(ctx)
+}
+
+// stale reports whether the stored entry of the given transaction
+// can be served when the server fails to provide a response.
+func (cache *Cache) stale(tx *transaction) bool {
+	if tx.entry == nil || tx.invalidate {
+		return false
+	}
+
+	cc := parseControl(tx.entry.Header)
+	if cc.has("must-revalidate") || (cache.opts.Shared && cc.has("proxy-revalidate")) {
+		return false
+	}
+
+	staleness := age(tx.entry, cache.now()) - lifetime(tx.entry, cache.opts.Shared)
+	if maxStale, ok := cc.duration("stale-if-error"); ok && staleness <= maxStale {
+		return true
+	}
+	return cache.opts.StaleIfError && (cache.opts.MaxStale <= 0 || staleness <= cache.opts.MaxStale)
+}
+
+// serve replies with the given stored entry, exposing its status and age.
+func (cache *Cache) serve(ctx *c.Context, entry *Entry, current time.Duration, status Status) {
+	ctx.Response = entry.response(ctx.Request)
+	ctx.Response.Header.Set("Age", seconds(current))
+	ctx.Set(statusKey, status)
+	ctx.Set("$age", current)
+	if status == Stale {
+		ctx.Set("$stale", true)
+	}
+}
+
+// update refreshes the stored entry with the header fields of a 304 response.
+func (cache *Cache) update(tx *transaction, res *http.Response) *Entry {
+	entry := *tx.entry
+	entry.Header = tx.entry.Header.Clone()
+	for name, values := range res.Header {
+		if name == "Content-Length" {
+			continue
+		}
+		entry.Header[name] = values
+	}
+	entry.RequestTime = tx.requestTime
+	entry.ResponseTime = cache.now()
+
+	cache.opts.Storage.Set(tx.key, &entry)
+	return &entry
+}
+
+// entry reads the response body and creates a new cache entry, or returns
+// nil if the body exceeds the maximum size. The response body is replaced
+// so it can still be consumed by the caller.
+func (cache *Cache) entry(tx *transaction, req *http.Request, res *http.Response) *Entry {
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, cache.opts.MaxBodySize+1))
+	if err != nil || int64(len(body)) > cache.opts.MaxBodySize {
+		res.Body = &reader{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+		return nil
+	}
+	res.Body.Close()
+	utils.WriteBodyBytes(res, body)
+
+	entry := &Entry{
+		StatusCode:   res.StatusCode,
+		Header:       res.Header.Clone(),
+		Body:         body,
+		RequestTime:  tx.requestTime,
+		ResponseTime: cache.now(),
+	}
+
+	for _, field := range res.Header["Vary"] {
+		for _, name := range strings.Split(field, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if entry.Vary == nil {
+				entry.Vary = make(http.Header)
+			}
+			entry.Vary[name] = req.Header[name]
+		}
+	}
+
+	return entry
+}
+
+// validate adds the conditional header fields required to revalidate
+// the given entry, reporting whether the entry can be revalidated.
+func validate(req *http.Request, entry *Entry) bool {
+	etag, modified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+	if etag == "" && modified == "" {
+		return false
+	}
+	// Conditional requests defined by the user take precedence
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return false
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+	return true
+}
+
+// key returns the storage key for the given request.
+func key(req *http.Request) string {
+	return req.URL.String()
+}
+
+// safe reports whether the given HTTP method is safe, and therefore
+// does not invalidate stored responses.
+func safe(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// reader joins an io.Reader with the io.Closer of the original body.
+type reader struct {
+	io.Reader
+	io.Closer
+}
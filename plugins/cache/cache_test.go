@@ -0,0 +1,328 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+func TestCacheFresh(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "hello world")
+	st.Expect(t, StatusOf(res.Context), Miss)
+
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "hello world")
+	st.Expect(t, res.Header.Get("Age"), "0")
+	st.Expect(t, StatusOf(res.Context), Hit)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestCacheStale(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cache := New(Options{})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cli := gentleman.New()
+	cli.Use(cache)
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+
+	now = now.Add(2 * time.Minute)
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, StatusOf(res.Context), Miss)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	var calls, validated int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&validated, 1)
+			w.Header().Set("X-Validated", "true")
+			w.WriteHeader(304)
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "hello world")
+
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "hello world")
+	st.Expect(t, res.Header.Get("X-Validated"), "true")
+	st.Expect(t, StatusOf(res.Context), Revalidated)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+	st.Expect(t, atomic.LoadInt32(&validated), int32(1))
+}
+
+func TestCacheConditionalRequest(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(304)
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	// Replies to the caller's own conditional requests are never stored
+	res, err := cli.Request().URL(ts.URL).SetHeader("If-None-Match", `"v1"`).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 304)
+
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "hello world")
+	st.Expect(t, StatusOf(res.Context), Miss)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestCacheNoStore(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-store, max-age=60")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Request().URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "hello world")
+	}
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestCacheVary(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept")
+		w.Write([]byte(r.Header.Get("Accept")))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	for _, accept := range []string{"text/plain", "text/plain", "application/json"} {
+		res, err := cli.Request().URL(ts.URL).SetHeader("Accept", accept).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), accept)
+	}
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	_, err = cli.Post().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, StatusOf(res.Context), Miss)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestCacheOnlyIfCached(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	res, err := cli.Request().URL(ts.URL).SetHeader("Cache-Control", "only-if-cached").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 504)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(0))
+}
+
+func TestCacheMaxBodySize(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{MaxBodySize: 5}))
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Request().URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "hello world")
+	}
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestCacheStaleIfError(t *testing.T) {
+	var fail int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cache := New(Options{StaleIfError: true})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cli := gentleman.New()
+	cli.Use(cache)
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.Stale, false)
+
+	// Server errors are answered with the stale stored response
+	now = now.Add(2 * time.Minute)
+	atomic.StoreInt32(&fail, 1)
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "hello world")
+	st.Expect(t, res.Stale, true)
+	// The apparent age may be rounded up, since the Date header has a one second resolution
+	st.Expect(t, res.Age >= 2*time.Minute && res.Age < 2*time.Minute+time.Second, true)
+	st.Expect(t, res.Header.Get("Age"), "120")
+	st.Expect(t, StatusOf(res.Context), Stale)
+
+	// Network errors are answered with the stale stored response
+	url := ts.URL
+	ts.Close()
+	res, err = cli.Request().URL(url).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "hello world")
+	st.Expect(t, res.Stale, true)
+}
+
+func TestCacheStaleIfErrorMaxStale(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello world"))
+	}))
+
+	cache := New(Options{StaleIfError: true, MaxStale: time.Minute})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cli := gentleman.New()
+	cli.Use(cache)
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	ts.Close()
+
+	now = now.Add(3 * time.Minute)
+	_, err = cli.Request().URL(ts.URL).Send()
+	st.Reject(t, err, nil)
+}
+
+func TestCacheStaleIfErrorDirective(t *testing.T) {
+	var fail int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(500)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60, stale-if-error=120")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cache := New(Options{})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cli := gentleman.New()
+	cli.Use(cache)
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	atomic.StoreInt32(&fail, 1)
+
+	now = now.Add(2 * time.Minute)
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.Stale, true)
+	st.Expect(t, res.String(), "hello world")
+
+	now = now.Add(2 * time.Minute)
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.Stale, false)
+	st.Expect(t, res.StatusCode, 500)
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// control represents the parsed directives of a Cache-Control header.
+type control map[string]string
+
+// parseControl parses the Cache-Control header fields.
+func parseControl(header http.Header) control {
+	cc := control{}
+	for _, field := range header["Cache-Control"] {
+		for _, directive := range strings.Split(field, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, value = directive[:i], strings.Trim(directive[i+1:], `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+	return cc
+}
+
+// has reports whether the given directive is present.
+func (cc control) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// duration returns the delta-seconds value of the given directive.
+func (cc control) duration(name string) (time.Duration, bool) {
+	value, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// heuristic defines the status codes that are cacheable by default,
+// as defined in RFC 9111 section 4.2.2.
+var heuristic = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// storable reports whether the given response can be stored,
+// as defined in RFC 9111 section 3.
+// Not modified and partial responses are never stored, since they're not complete:
+// 304 responses only refresh the stored ones.
+func storable(req *http.Request, res *http.Response, shared bool) bool {
+	if req.Method != "GET" {
+		return false
+	}
+	if res.StatusCode == http.StatusNotModified || res.StatusCode == http.StatusPartialContent {
+		return false
+	}
+
+	reqcc, rescc := parseControl(req.Header), parseControl(res.Header)
+	if reqcc.has("no-store") || rescc.has("no-store") {
+		return false
+	}
+	if shared && rescc.has("private") {
+		return false
+	}
+	if shared && req.Header.Get("Authorization") != "" &&
+		!rescc.has("public") && !rescc.has("s-maxage") && !rescc.has("must-revalidate") {
+		return false
+	}
+	if res.Header.Get("Vary") == "*" {
+		return false
+	}
+
+	// The response must be explicitly or heuristically cacheable, or revalidable
+	if rescc.has("max-age") || (shared && rescc.has("s-maxage")) || rescc.has("public") ||
+		res.Header.Get("Expires") != "" {
+		return true
+	}
+	if !heuristic[res.StatusCode] {
+		return false
+	}
+	return res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != "" || rescc.has("no-cache")
+}
+
+// lifetime returns the freshness lifetime of the given entry,
+// as defined in RFC 9111 section 4.2.1.
+func lifetime(e *Entry, shared bool) time.Duration {
+	cc := parseControl(e.Header)
+	if cc.has("no-cache") {
+		return 0
+	}
+	if shared {
+		if maxAge, ok := cc.duration("s-maxage"); ok {
+			return maxAge
+		}
+	}
+	if maxAge, ok := cc.duration("max-age"); ok {
+		return maxAge
+	}
+
+	date := responseDate(e)
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil || t.Before(date) {
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	// Heuristic freshness: 10% of the time since the last modification
+	if modified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && heuristic[e.StatusCode] {
+		if date.After(modified) {
+			return date.Sub(modified) / 10
+		}
+	}
+
+	return 0
+}
+
+// age returns the current age of the given entry,
+// as defined in RFC 9111 section 4.2.3.
+func age(e *Entry, now time.Time) time.Duration {
+	apparent := e.ResponseTime.Sub(responseDate(e))
+	if apparent < 0 {
+		apparent = 0
+	}
+
+	var value time.Duration
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		value = time.Duration(seconds) * time.Second
+	}
+
+	corrected := value + e.ResponseTime.Sub(e.RequestTime)
+	if apparent > corrected {
+		corrected = apparent
+	}
+
+	return corrected + now.Sub(e.ResponseTime)
+}
+
+// fresh reports whether the given entry can be served without
+// revalidation for the given request, based on its current age.
+func fresh(req *http.Request, e *Entry, current time.Duration, shared bool) bool {
+	reqcc := parseControl(req.Header)
+	if reqcc.has("no-cache") || req.Header.Get("Pragma") == "no-cache" {
+		return false
+	}
+
+	ttl := lifetime(e, shared)
+	if maxAge, ok := reqcc.duration("max-age"); ok && maxAge < ttl {
+		ttl = maxAge
+	}
+	if minFresh, ok := reqcc.duration("min-fresh"); ok {
+		current += minFresh
+	}
+	if current < ttl {
+		return true
+	}
+
+	// Stale responses may be explicitly accepted by the client
+	if parseControl(e.Header).has("must-revalidate") || !reqcc.has("max-stale") {
+		return false
+	}
+	maxStale, ok := reqcc.duration("max-stale")
+	return !ok || current-ttl < maxStale
+}
+
+// responseDate returns the Date header value of the given entry,
+// falling back to the time the response was received.
+func responseDate(e *Entry) time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+)
+
+func TestParseControl(t *testing.T) {
+	header := http.Header{"Cache-Control": {`public, Max-Age=60`, `no-cache="Set-Cookie"`}}
+	cc := parseControl(header)
+	st.Expect(t, cc.has("public"), true)
+	st.Expect(t, cc["no-cache"], "Set-Cookie")
+	maxAge, ok := cc.duration("max-age")
+	st.Expect(t, ok, true)
+	st.Expect(t, maxAge, 60*time.Second)
+	_, ok = cc.duration("s-maxage")
+	st.Expect(t, ok, false)
+}
+
+func TestLifetime(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		header http.Header
+		shared bool
+		ttl    time.Duration
+	}{
+		{http.Header{"Cache-Control": {"max-age=60"}}, false, time.Minute},
+		{http.Header{"Cache-Control": {"max-age=60, s-maxage=120"}}, false, time.Minute},
+		{http.Header{"Cache-Control": {"max-age=60, s-maxage=120"}}, true, 2 * time.Minute},
+		{http.Header{"Cache-Control": {"no-cache, max-age=60"}}, false, 0},
+		{http.Header{"Expires": {date.Add(time.Hour).Format(http.TimeFormat)}}, false, time.Hour},
+		{http.Header{"Expires": {"0"}}, false, 0},
+		{http.Header{"Last-Modified": {date.Add(-10 * time.Hour).Format(http.TimeFormat)}}, false, time.Hour},
+	}
+
+	for _, test := range cases {
+		test.header.Set("Date", date.Format(http.TimeFormat))
+		entry := &Entry{StatusCode: 200, Header: test.header, ResponseTime: date}
+		st.Expect(t, lifetime(entry, test.shared), test.ttl)
+	}
+}
+
+func TestAge(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := &Entry{
+		Header:       http.Header{"Date": {date.Format(http.TimeFormat)}, "Age": {"10"}},
+		RequestTime:  date.Add(time.Second),
+		ResponseTime: date.Add(2 * time.Second),
+	}
+	st.Expect(t, age(entry, date.Add(time.Minute)), 11*time.Second+58*time.Second)
+}
+
+func TestFresh(t *testing.T) {
+	entry := &Entry{StatusCode: 200, Header: http.Header{"Cache-Control": {"max-age=60"}}}
+	req, _ := http.NewRequest("GET", "http://foo.com", nil)
+	st.Expect(t, fresh(req, entry, 30*time.Second, false), true)
+	st.Expect(t, fresh(req, entry, 90*time.Second, false), false)
+
+	req.Header.Set("Cache-Control", "max-stale=60")
+	st.Expect(t, fresh(req, entry, 90*time.Second, false), true)
+	req.Header.Set("Cache-Control", "min-fresh=40")
+	st.Expect(t, fresh(req, entry, 30*time.Second, false), false)
+	req.Header.Set("Cache-Control", "max-age=10")
+	st.Expect(t, fresh(req, entry, 30*time.Second, false), false)
+	req.Header.Set("Cache-Control", "no-cache")
+	st.Expect(t, fresh(req, entry, 0, false), false)
+}
+
+func TestStorable(t *testing.T) {
+	cases := []struct {
+		method   string
+		auth     bool
+		status   int
+		header   http.Header
+		shared   bool
+		storable bool
+	}{
+		{"GET", false, 200, http.Header{"Cache-Control": {"max-age=60"}}, false, true},
+		{"POST", false, 200, http.Header{"Cache-Control": {"max-age=60"}}, false, false},
+		{"GET", false, 200, http.Header{"Cache-Control": {"no-store"}}, false, false},
+		{"GET", false, 200, http.Header{"Cache-Control": {"private, max-age=60"}}, false, true},
+		{"GET", false, 200, http.Header{"Cache-Control": {"private, max-age=60"}}, true, false},
+		{"GET", true, 200, http.Header{"Cache-Control": {"max-age=60"}}, true, false},
+		{"GET", true, 200, http.Header{"Cache-Control": {"public, max-age=60"}}, true, true},
+		{"GET", false, 200, http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}, false, false},
+		{"GET", false, 200, http.Header{"Etag": {`"foo"`}}, false, true},
+		{"GET", false, 500, http.Header{"Etag": {`"foo"`}}, false, false},
+		{"GET", false, 200, http.Header{}, false, false},
+		{"GET", false, 304, http.Header{"Cache-Control": {"max-age=60"}}, false, false},
+		{"GET", false, 304, http.Header{"Expires": {"Thu, 01 Dec 2094 16:00:00 GMT"}}, false, false},
+		{"GET", false, 206, http.Header{"Cache-Control": {"max-age=60"}}, false, false},
+		{"GET", false, 206, http.Header{"Etag": {`"foo"`}}, false, false},
+	}
+
+	for _, test := range cases {
+		req, _ := http.NewRequest(test.method, "http://foo.com", nil)
+		if test.auth {
+			req.Header.Set("Authorization", "Bearer foo")
+		}
+		res := &http.Response{StatusCode: test.status, Header: test.header}
+		st.Expect(t, storable(req, res, test.shared), test.storable)
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File implements a Storage that persists entries as JSON files
+// in a directory, named by the SHA-256 hash of their key.
+//
+// Storage failures are not reported: entries that cannot be read
+// or written are simply treated as missing.
+type File struct {
+	mtx sync.RWMutex
+	dir string
+}
+
+// NewFile creates a new filesystem storage in the given directory,
+// which is created on demand.
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+// Get retrieves the entry stored by the given key, if any.
+func (f *File) Get(key string) (*Entry, bool) {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+	buf, err := ioutil.ReadFile(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	entry := &Entry{}
+	if err := json.Unmarshal(buf, entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores the given entry by key, replacing any existent entry.
+func (f *File) Set(key string, entry *Entry) {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return
+	}
+
+	// Write to a temporary file first, so entries are replaced atomically
+	tmp, err := ioutil.TempFile(f.dir, ".entry-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Delete removes the entry stored by the given key, if any.
+func (f *File) Delete(key string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	os.Remove(f.path(key))
+}
+
+func (f *File) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:]))
+}
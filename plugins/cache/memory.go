@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// item stores a keyed entry in the LRU list.
+type item struct {
+	key   string
+	entry *Entry
+}
+
+// Memory implements an in-memory Storage that evicts
+// the least recently used entries once its capacity is reached.
+type Memory struct {
+	mtx      sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// NewMemory creates a new in-memory storage holding up to the given number
+// of entries. Zero or negative capacity means no limit.
+func NewMemory(capacity int) *Memory {
+	return &Memory{capacity: capacity, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get retrieves the entry stored by the given key, if any.
+func (m *Memory) Get(key string) (*Entry, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	m.ll.MoveToFront(el)
+	return el.Value.(*item).entry, true
+}
+
+// Set stores the given entry by key, evicting the least recently used entry if required.
+func (m *Memory) Set(key string, entry *Entry) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if el, ok := m.items[key]; ok {
+		el.Value.(*item).entry = entry
+		m.ll.MoveToFront(el)
+		return
+	}
+
+	m.items[key] = m.ll.PushFront(&item{key: key, entry: entry})
+	if m.capacity > 0 && m.ll.Len() > m.capacity {
+		el := m.ll.Back()
+		m.ll.Remove(el)
+		delete(m.items, el.Value.(*item).key)
+	}
+}
+
+// Delete removes the entry stored by the given key, if any.
+func (m *Memory) Delete(key string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.ll.Remove(el)
+		delete(m.items, key)
+	}
+}
+
+// Len returns the number of stored entries.
+func (m *Memory) Len() int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.ll.Len()
+}
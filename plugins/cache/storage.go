@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"net/http"
+	"time"
+
+	"gopkg.in/h2non/gentleman.v2/utils"
+)
+
+// Storage defines the interface that must be implemented by cache storage backends.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Get retrieves the cache entry stored by the given key, if any.
+	Get(key string) (*Entry, bool)
+
+	// Set stores the given cache entry by key, replacing any existent entry.
+	Set(key string, entry *Entry)
+
+	// Delete removes the cache entry stored by the given key, if any.
+	Delete(key string)
+}
+
+// Entry represents a stored HTTP response.
+type Entry struct {
+	// StatusCode stores the response status code.
+	StatusCode int
+
+	// Header stores the response header fields.
+	Header http.Header
+
+	// Body stores the response body.
+	Body []byte
+
+	// Vary stores the request header fields nominated by the response Vary header.
+	Vary http.Header
+
+	// RequestTime stores the time when the request that originated the response was sent.
+	RequestTime time.Time
+
+	// ResponseTime stores the time when the response was received.
+	ResponseTime time.Time
+}
+
+// matches reports whether the entry was stored for a request
+// with the same values for the fields nominated by the Vary header.
+func (e *Entry) matches(req *http.Request) bool {
+	for name, values := range e.Vary {
+		if name == "*" {
+			return false
+		}
+		if !equal(req.Header[name], values) {
+			return false
+		}
+	}
+	return true
+}
+
+// response creates a new http.Response based on the entry for the given request.
+func (e *Entry) response(req *http.Request) *http.Response {
+	res := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     e.Header.Clone(),
+		Request:    req,
+	}
+	utils.ReplyWithStatus(res, e.StatusCode)
+	utils.WriteBodyBytes(res, e.Body)
+	return res
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	m := NewMemory(2)
+	m.Set("foo", &Entry{StatusCode: 200})
+	m.Set("bar", &Entry{StatusCode: 201})
+
+	// Touch foo, so bar becomes the least recently used entry
+	_, ok := m.Get("foo")
+	st.Expect(t, ok, true)
+
+	m.Set("baz", &Entry{StatusCode: 202})
+	st.Expect(t, m.Len(), 2)
+	_, ok = m.Get("bar")
+	st.Expect(t, ok, false)
+
+	entry, ok := m.Get("baz")
+	st.Expect(t, ok, true)
+	st.Expect(t, entry.StatusCode, 202)
+
+	m.Delete("foo")
+	_, ok = m.Get("foo")
+	st.Expect(t, ok, false)
+	st.Expect(t, m.Len(), 1)
+}
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gentleman-cache")
+	st.Expect(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	f := NewFile(dir + "/entries")
+	_, ok := f.Get("foo")
+	st.Expect(t, ok, false)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	f.Set("foo", &Entry{
+		StatusCode:   200,
+		Header:       http.Header{"Etag": {`"foo"`}},
+		Body:         []byte("hello world"),
+		Vary:         http.Header{"Accept": {"text/plain"}},
+		ResponseTime: now,
+	})
+
+	entry, ok := f.Get("foo")
+	st.Expect(t, ok, true)
+	st.Expect(t, entry.StatusCode, 200)
+	st.Expect(t, entry.Header.Get("ETag"), `"foo"`)
+	st.Expect(t, string(entry.Body), "hello world")
+	st.Expect(t, entry.Vary.Get("Accept"), "text/plain")
+	st.Expect(t, entry.ResponseTime.Equal(now), true)
+
+	f.Delete("foo")
+	_, ok = f.Get("foo")
+	st.Expect(t, ok, false)
+}
+
+func TestEntryMatches(t *testing.T) {
+	entry := &Entry{Vary: http.Header{"Accept": {"text/plain"}}}
+	req, _ := http.NewRequest("GET", "http://foo.com", nil)
+	st.Expect(t, entry.matches(req), false)
+	req.Header.Set("Accept", "text/plain")
+	st.Expect(t, entry.matches(req), true)
+}
@@ -0,0 +1,123 @@
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// Request represents a recorded HTTP request.
+type Request struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// Response represents a recorded HTTP response.
+type Response struct {
+	StatusCode   int         `json:"statusCode"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// Interaction represents a recorded HTTP request/response pair.
+type Interaction struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+}
+
+// Cassette stores the recorded interactions, persisted as a JSON file.
+type Cassette struct {
+	// Path stores the cassette file path.
+	Path string
+
+	// Interactions stores the recorded interactions.
+	Interactions []*Interaction
+
+	mtx  sync.Mutex
+	used []bool
+}
+
+// Load loads the cassette stored in the given file path.
+func Load(path string) (*Cassette, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{Path: path}
+	if err := json.Unmarshal(buf, &c.Interactions); err != nil {
+		return nil, err
+	}
+	c.used = make([]bool, len(c.Interactions))
+	return c, nil
+}
+
+// Add adds a new interaction to the cassette.
+func (c *Cassette) Add(i *Interaction) {
+	c.mtx.Lock()
+	c.Interactions = append(c.Interactions, i)
+	c.used = append(c.used, false)
+	c.mtx.Unlock()
+}
+
+// Save writes the cassette interactions as JSON in its file path.
+func (c *Cassette) Save() error {
+	c.mtx.Lock()
+	buf, err := json.MarshalIndent(c.Interactions, "", "  ")
+	c.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, append(buf, '\n'), 0644)
+}
+
+// Find returns the first not yet replayed interaction matching the given request,
+// falling back to the last matching one, so interactions can be replayed in order.
+func (c *Cassette) Find(req *Request, match Matcher) (*Interaction, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	found := -1
+	for i, interaction := range c.Interactions {
+		if !match(req, interaction.Request) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction, true
+		}
+		found = i
+	}
+	if found < 0 {
+		return nil, false
+	}
+	return c.Interactions[found], true
+}
+
+// encode returns the text representation of the given body,
+// using base64 encoding for binary data.
+func encode(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decode returns the raw body of the given text representation.
+func decode(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,60 @@
+package cassette
+
+import (
+	"net/http"
+)
+
+// Matcher reports whether the given request matches a recorded request.
+type Matcher func(req, recorded *Request) bool
+
+// Method matches requests by HTTP method.
+func Method(req, recorded *Request) bool {
+	return req.Method == recorded.Method
+}
+
+// URL matches requests by full URL, including the query string.
+func URL(req, recorded *Request) bool {
+	return req.URL == recorded.URL
+}
+
+// Body matches requests by body.
+func Body(req, recorded *Request) bool {
+	return req.Body == recorded.Body && req.BodyEncoding == recorded.BodyEncoding
+}
+
+// Headers returns a Matcher that matches requests by the given header fields.
+func Headers(names ...string) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, name := range names {
+			name = http.CanonicalHeaderKey(name)
+			if !equal(req.Header[name], recorded.Header[name]) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// All returns a Matcher that matches requests satisfying all the given matchers.
+func All(matchers ...Matcher) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, match := range matchers {
+			if !match(req, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
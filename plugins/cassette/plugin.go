@@ -0,0 +1,233 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/plugins/redirect"
+)
+
+// Mode defines how the cassette plugin deals with the network.
+type Mode int
+
+const (
+	// RecordOnce records the real exchanges when the cassette file does not exist yet,
+	// otherwise replays the recorded interactions without network access.
+	RecordOnce Mode = iota
+
+	// ReplayOnly replays the recorded interactions, failing if the cassette
+	// file does not exist or no recorded interaction matches a request.
+	ReplayOnly
+
+	// Passthrough performs the real exchanges, ignoring the cassette.
+	Passthrough
+)
+
+// ErrNoMatch is returned when no recorded interaction matches a request in replay mode.
+var ErrNoMatch = errors.New("cassette: no matching interaction recorded")
+
+// Options stores the cassette plugin options.
+type Options struct {
+	// Mode defines the cassette mode. Defaults to RecordOnce.
+	Mode Mode
+
+	// Match defines the request matchers used on replay. Defaults to Method and URL.
+	Match []Matcher
+
+	// IgnoreHeaders defines the request header fields not stored in the cassette.
+	// Defaults to redirect.SensitiveHeaders plus Cookie.
+	IgnoreHeaders []string
+}
+
+// Plugin implements a plugin that records real exchanges in a cassette file,
+// then replays them via the "intercept" phase with no network access.
+type Plugin struct {
+	// Plugin implements the plugin interface.
+	*p.Layer
+
+	// Cassette stores the cassette used to record or replay interactions.
+	Cassette *Cassette
+
+	opts      Options
+	match     Matcher
+	recording bool
+	err       error
+}
+
+// New creates a new cassette plugin that records or replays
+// the interactions of the given cassette file path.
+func New(path string, opts Options) *Plugin {
+	if len(opts.Match) == 0 {
+		opts.Match = []Matcher{Method, URL}
+	}
+	if opts.IgnoreHeaders == nil {
+		opts.IgnoreHeaders = append(append([]string(nil), redirect.SensitiveHeaders...), "Cookie")
+	}
+
+	pl := &Plugin{Layer: p.New(), opts: opts, match: All(opts.Match...)}
+	pl.Cassette, pl.err = Load(path)
+	if os.IsNotExist(pl.err) && opts.Mode != ReplayOnly {
+		pl.Cassette, pl.err = &Cassette{Path: path}, nil
+		pl.recording = true
+	}
+
+	pl.SetHandlers(p.Handlers{
+		"before dial": pl.before,
+		"after dial":  pl.after,
+	})
+	return pl
+}
+
+// Recording reports whether the plugin records real exchanges.
+func (pl *Plugin) Recording() bool {
+	return pl.recording && pl.opts.Mode != Passthrough
+}
+
+func (pl *Plugin) before(ctx *c.Context, h c.Handler) {
+	if pl.opts.Mode == Passthrough {
+		h.Next(ctx)
+		return
+	}
+	if pl.err != nil {
+		h.Error(ctx, pl.err)
+		return
+	}
+
+	req, err := pl.request(ctx.Request)
+	if err != nil {
+		h.Error(ctx, err)
+		return
+	}
+	if pl.recording {
+		ctx.Set(pl, req)
+		h.Next(ctx)
+		return
+	}
+
+	interaction, ok := pl.Cassette.Find(req, pl.match)
+	if !ok {
+		h.Error(ctx, fmt.Errorf("%w: %s %s", ErrNoMatch, req.Method, req.URL))
+		return
+	}
+	res, err := replay(ctx.Request, interaction.Response)
+	if err != nil {
+		h.Error(ctx, err)
+		return
+	}
+
+	// Setting the response makes the dispatcher switch to the "intercept" phase
+	ctx.Response = res
+	h.Next(ctx)
+}
+
+func (pl *Plugin) after(ctx *c.Context, h c.Handler) {
+	req, ok := ctx.Get(pl).(*Request)
+	if !ok {
+		h.Next(ctx)
+		return
+	}
+
+	res, err := record(ctx.Response)
+	if err != nil {
+		h.Error(ctx, err)
+		return
+	}
+	pl.Cassette.Add(&Interaction{Request: req, Response: res})
+	if err := pl.Cassette.Save(); err != nil {
+		h.Error(ctx, err)
+		return
+	}
+	h.Next(ctx)
+}
+
+// request returns the cassette representation of the given request, preserving its body.
+func (pl *Plugin) request(req *http.Request) (*Request, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	header := req.Header.Clone()
+	for _, name := range pl.opts.IgnoreHeaders {
+		header.Del(name)
+	}
+	if len(header) == 0 {
+		header = nil
+	}
+
+	r := &Request{Method: req.Method, URL: req.URL.String(), Header: header}
+	r.Body, r.BodyEncoding = encode(body)
+	return r, nil
+}
+
+// requestBody reads the request body, preserving it.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	buf, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	return buf, nil
+}
+
+// record returns the cassette representation of the given response, preserving its body.
+func record(res *http.Response) (*Response, error) {
+	var body []byte
+	if res.Body != nil && res.Body != http.NoBody {
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(buf))
+		body = buf
+	}
+
+	r := &Response{StatusCode: res.StatusCode, Header: res.Header.Clone()}
+	r.Body, r.BodyEncoding = encode(body)
+	return r, nil
+}
+
+// replay builds the HTTP response of the given recorded response.
+func replay(req *http.Request, r *Response) (*http.Response, error) {
+	body, err := decode(r.Body, r.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strings.TrimSpace(fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode))),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package cassette
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+func newServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(201)
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+	}))
+}
+
+func TestRecordOnce(t *testing.T) {
+	var hits int32
+	ts := newServer(&hits)
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "fixtures", "cassette.json")
+
+	pl := New(path, Options{})
+	st.Expect(t, pl.Recording(), true)
+	cli := gentleman.New()
+	cli.Use(pl)
+
+	res, err := cli.Post().URL(ts.URL+"/foo").SetHeader("Authorization", "secret").BodyString("hello").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 201)
+	st.Expect(t, res.String(), "POST /foo hello")
+	st.Expect(t, atomic.LoadInt32(&hits), int32(1))
+
+	c, err := Load(path)
+	st.Expect(t, err, nil)
+	st.Expect(t, len(c.Interactions), 1)
+	st.Expect(t, c.Interactions[0].Request.Body, "hello")
+	st.Expect(t, c.Interactions[0].Request.Header.Get("Authorization"), "")
+	st.Expect(t, c.Interactions[0].Response.Body, "POST /foo hello")
+
+	// A new plugin replays the existing cassette with no network
+	pl = New(path, Options{})
+	st.Expect(t, pl.Recording(), false)
+	cli = gentleman.New()
+	cli.Use(pl)
+
+	res, err = cli.Post().URL(ts.URL + "/foo").BodyString("hello").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 201)
+	st.Expect(t, res.Header.Get("Content-Type"), "text/plain")
+	st.Expect(t, res.String(), "POST /foo hello")
+	st.Expect(t, atomic.LoadInt32(&hits), int32(1))
+
+	_, err = cli.Get().URL(ts.URL + "/bar").Send()
+	st.Expect(t, errors.Is(err, ErrNoMatch), true)
+	st.Expect(t, atomic.LoadInt32(&hits), int32(1))
+}
+
+func TestReplayOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	cli := gentleman.New()
+	cli.Use(New(path, Options{Mode: ReplayOnly}))
+	_, err := cli.Get().URL("http://localhost:1/foo").Send()
+	st.Reject(t, err, nil)
+
+	c := &Cassette{Path: path}
+	c.Add(&Interaction{
+		Request:  &Request{Method: "GET", URL: "http://localhost:1/foo"},
+		Response: &Response{StatusCode: 200, Body: "first"},
+	})
+	c.Add(&Interaction{
+		Request:  &Request{Method: "GET", URL: "http://localhost:1/foo"},
+		Response: &Response{StatusCode: 200},
+	})
+	c.Interactions[1].Response.Body, c.Interactions[1].Response.BodyEncoding = encode([]byte{0xff, 0xfe})
+	st.Expect(t, c.Save(), nil)
+
+	cli = gentleman.New()
+	cli.Use(New(path, Options{Mode: ReplayOnly}))
+
+	// Interactions are replayed in order, repeating the last match
+	for _, body := range []string{"first", "\xff\xfe", "\xff\xfe"} {
+		res, err := cli.Get().URL("http://localhost:1/foo").Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 200)
+		st.Expect(t, res.String(), body)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	var hits int32
+	ts := newServer(&hits)
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	pl := New(path, Options{Mode: Passthrough})
+	st.Expect(t, pl.Recording(), false)
+	cli := gentleman.New()
+	cli.Use(pl)
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Get().URL(ts.URL + "/foo").Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "GET /foo ")
+	}
+	st.Expect(t, atomic.LoadInt32(&hits), int32(2))
+	_, err := Load(path)
+	st.Reject(t, err, nil)
+}
+
+func TestMatchers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := &Cassette{Path: path}
+	for _, tenant := range []string{"a", "b"} {
+		c.Add(&Interaction{
+			Request: &Request{
+				Method: "POST",
+				URL:    "http://localhost:1/foo",
+				Header: http.Header{"X-Tenant": {tenant}},
+				Body:   "data",
+			},
+			Response: &Response{StatusCode: 200, Body: tenant},
+		})
+	}
+	st.Expect(t, c.Save(), nil)
+
+	cli := gentleman.New()
+	cli.Use(New(path, Options{Mode: ReplayOnly, Match: []Matcher{Method, URL, Body, Headers("x-tenant")}}))
+
+	res, err := cli.Post().URL("http://localhost:1/foo").SetHeader("X-Tenant", "b").BodyString("data").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "b")
+
+	_, err = cli.Post().URL("http://localhost:1/foo").SetHeader("X-Tenant", "b").BodyString("other").Send()
+	st.Expect(t, errors.Is(err, ErrNoMatch), true)
+
+	_, err = cli.Post().URL("http://localhost:1/foo").SetHeader("X-Tenant", "c").BodyString("data").Send()
+	st.Expect(t, errors.Is(err, ErrNoMatch), true)
+}
@@ -3,10 +3,12 @@ package compression
 import (
 	c "gopkg.in/h2non/gentleman.v2/context"
 	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
 	"net/http"
 )
 
-// Disable disables the authorization basic header in the outgoing request
+// Disable disables the transparent gzip compression of the HTTP transport.
+// The transport is cloned on write, leaving the shared one untouched.
 func Disable() p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		// Assert http.Transport to work with the instance
@@ -17,9 +19,13 @@ func Disable() p.Plugin {
 		}
 
 		// Override the http.Client transport
-		transport.DisableCompression = true
-		ctx.Client.Transport = transport
+		ctx.Client.Transport = utils.Transports(ctx).Derive(transport, key{}, func(transport *http.Transport) {
+			transport.DisableCompression = true
+		})
 
 		h.Next(ctx)
 	})
 }
+
+// key identifies the transports derived by the plugin.
+type key struct{}
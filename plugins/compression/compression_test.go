@@ -14,6 +14,10 @@ func TestDisableCompression(t *testing.T) {
 	st.Expect(t, fn.called, true)
 	transport := ctx.Client.Transport.(*http.Transport)
 	st.Expect(t, transport.DisableCompression, true)
+
+	// The shared transport is cloned on write
+	st.Expect(t, transport != http.DefaultTransport, true)
+	st.Expect(t, http.DefaultTransport.(*http.Transport).DisableCompression, false)
 }
 
 type handler struct {
@@ -0,0 +1,62 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
+)
+
+// DialContextFunc represents the function used to dial network connections.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Unix dials the given Unix domain socket path for every outgoing request,
+// regardless of the URL host, which is still used for the Host header.
+// The proxy is disabled, since the socket is always dialed.
+func Unix(path string) p.Plugin {
+	dialer := &net.Dialer{}
+	return dial(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}, key{unix: path}, true)
+}
+
+// Context uses the given function to dial the network connections of outgoing requests.
+// Functions cannot be compared, so every plugin instance derives its own transport:
+// register it once at client level instead of per request to reuse the connections.
+func Context(fn DialContextFunc) p.Plugin {
+	return dial(fn, key{id: new(int)}, false)
+}
+
+// dial creates a plugin defining the transport dialer.
+// The transport is cloned on write, leaving the shared one untouched.
+func dial(fn DialContextFunc, k key, noProxy bool) p.Plugin {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		// Assert http.Transport to work with the instance
+		transport, ok := ctx.Client.Transport.(*http.Transport)
+		if !ok {
+			// If using a custom transport, just ignore it
+			h.Next(ctx)
+			return
+		}
+
+		// Override the http.Client transport
+		ctx.Client.Transport = utils.Transports(ctx).Derive(transport, k, func(transport *http.Transport) {
+			transport.DialContext = fn
+			if noProxy {
+				transport.Proxy = nil
+			}
+		})
+
+		h.Next(ctx)
+	})
+}
+
+// key identifies the transports derived by a dialer,
+// either by its Unix socket path or by plugin instance.
+type key struct {
+	unix string
+	id   *int
+}
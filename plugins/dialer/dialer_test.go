@@ -0,0 +1,111 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	gcontext "gopkg.in/h2non/gentleman.v2/context"
+	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+)
+
+func TestUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gentleman.sock")
+	listener, err := net.Listen("unix", path)
+	st.Expect(t, err, nil)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + r.URL.Path))
+	}))
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.URL("http://docker/v1.41/info")
+	cli.Use(Unix(path))
+
+	res, err := cli.Request().Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "docker/v1.41/info")
+}
+
+func TestContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+
+	var dialed string
+	cli := gentleman.New()
+	cli.URL("http://backend:8080")
+	cli.Use(Context(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+	}))
+
+	// The custom dialer is preserved by the timeout plugin
+	res, err := cli.Request().Use(timeout.Dial(time.Second, time.Second)).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "backend:8080")
+	st.Expect(t, dialed, "backend:8080")
+}
+
+func TestContextNetDialer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var controlled int32
+	d := &net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		atomic.AddInt32(&controlled, 1)
+		return nil
+	}}
+
+	cli := gentleman.New()
+	cli.URL(ts.URL)
+	cli.Use(Context(d.DialContext))
+	cli.Use(timeout.Dial(time.Second, time.Second))
+
+	// The custom net.Dialer settings are preserved by the timeout plugin
+	res, err := cli.Request().Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "ok")
+	st.Expect(t, atomic.LoadInt32(&controlled), int32(1))
+}
+
+func TestTransportIsolation(t *testing.T) {
+	ctx := gcontext.New()
+	fn := newHandler()
+	Unix("/tmp/gentleman.sock").Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+
+	transport := ctx.Client.Transport.(*http.Transport)
+	st.Expect(t, transport != http.DefaultTransport, true)
+	st.Expect(t, transport.Proxy == nil, true)
+	st.Expect(t, http.DefaultTransport.(*http.Transport).Proxy != nil, true)
+}
+
+type handler struct {
+	fn     gcontext.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = gcontext.NewHandler(func(c *gcontext.Context) {
+		h.called = true
+	})
+	return h
+}
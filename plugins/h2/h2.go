@@ -0,0 +1,163 @@
+package h2
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
+
+	"golang.org/x/net/http2"
+)
+
+// Options stores the HTTP/2 transport options.
+type Options struct {
+	// ReadIdleTimeout defines the timeout after which a health check ping frame
+	// is sent if no frame was received on the connection. Zero disables health checks.
+	ReadIdleTimeout time.Duration
+
+	// PingTimeout defines the timeout after which the connection is closed
+	// if no ping response is received. Defaults to 15 seconds.
+	PingTimeout time.Duration
+
+	// WriteByteTimeout defines the timeout after which the connection is closed
+	// if no data can be written to it. Zero means no timeout.
+	WriteByteTimeout time.Duration
+
+	// StrictMaxConcurrentStreams makes the server SETTINGS_MAX_CONCURRENT_STREAMS
+	// a global limit, instead of opening new connections when reached.
+	StrictMaxConcurrentStreams bool
+}
+
+// Enable enables HTTP/2 over TLS in the HTTP transport, negotiated via ALPN
+// with fallback to HTTP/1.1. The transport is cloned on write, leaving the shared one untouched.
+func Enable(opts Options) p.Plugin {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		// Assert http.Transport to work with the instance
+		transport, ok := ctx.Client.Transport.(*http.Transport)
+		if !ok {
+			// If using a custom transport, just ignore it
+			h.Next(ctx)
+			return
+		}
+
+		transport, err := enable(ctx, transport, opts)
+		if err != nil {
+			h.Error(ctx, err)
+			return
+		}
+
+		ctx.Client.Transport = transport
+		h.Next(ctx)
+	})
+}
+
+// Cleartext enables HTTP/2 with prior knowledge over cleartext TCP (h2c) for
+// http:// URLs, and HTTP/2 over TLS for https:// URLs.
+//
+// Since the resulting transport is not an http.Transport, other transport plugins,
+// such as tls, proxy or pool, must be registered before it.
+func Cleartext(opts Options) p.Plugin {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		// Assert http.Transport to work with the instance
+		transport, ok := ctx.Client.Transport.(*http.Transport)
+		if !ok {
+			// If using a custom transport, just ignore it
+			h.Next(ctx)
+			return
+		}
+
+		transport, err := enable(ctx, transport, opts)
+		if err != nil {
+			h.Error(ctx, err)
+			return
+		}
+
+		ctx.Client.Transport = utils.Transports(ctx).RoundTripper(transport, key{opts}, func(transport *http.Transport) http.RoundTripper {
+			return newCleartext(transport, opts)
+		})
+		h.Next(ctx)
+	})
+}
+
+// key identifies the transports derived for the given HTTP/2 options.
+type key struct {
+	opts Options
+}
+
+// enable returns the clone of the given transport with HTTP/2 enabled.
+func enable(ctx *c.Context, transport *http.Transport, opts Options) (*http.Transport, error) {
+	var err error
+	transport = utils.Transports(ctx).Derive(transport, key{opts}, func(transport *http.Transport) {
+		_, err = configure(transport, opts)
+	})
+	return transport, err
+}
+
+// cleartext implements an http.RoundTripper performing http:// requests
+// via h2c with prior knowledge, and any other via the HTTP/2 enabled TLS transport.
+type cleartext struct {
+	h2c *http2.Transport
+	tls *http.Transport
+}
+
+// newCleartext creates a new h2c transport based on the given HTTP/2 enabled transport,
+// which is used for TLS connections.
+func newCleartext(transport *http.Transport, opts Options) *cleartext {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	h2c := &http2.Transport{
+		AllowHTTP:       true,
+		IdleConnTimeout: transport.IdleConnTimeout,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+	options(h2c, opts)
+
+	return &cleartext{h2c: h2c, tls: transport}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *cleartext) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.tls.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *cleartext) CloseIdleConnections() {
+	t.h2c.CloseIdleConnections()
+	t.tls.CloseIdleConnections()
+}
+
+// configure enables HTTP/2 in the given transport, returning the HTTP/2 transport.
+func configure(transport *http.Transport, opts Options) (*http2.Transport, error) {
+	// Avoid modifying a TLS config shared with other transports
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+
+	h2, err := http2.ConfigureTransports(transport)
+	if err != nil {
+		return nil, err
+	}
+	options(h2, opts)
+	return h2, nil
+}
+
+// options applies the given options to the HTTP/2 transport.
+func options(h2 *http2.Transport, opts Options) {
+	h2.ReadIdleTimeout = opts.ReadIdleTimeout
+	h2.PingTimeout = opts.PingTimeout
+	h2.WriteByteTimeout = opts.WriteByteTimeout
+	h2.StrictMaxConcurrentStreams = opts.StrictMaxConcurrentStreams
+}
@@ -0,0 +1,111 @@
+package h2
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+	gtls "gopkg.in/h2non/gentleman.v2/plugins/tls"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestEnable(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	config := &tls.Config{RootCAs: pool}
+
+	cli := gentleman.New()
+	cli.URL(ts.URL)
+	cli.Use(gtls.Config(config))
+	cli.Use(Enable(Options{ReadIdleTimeout: time.Second}))
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Request().Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 200)
+		st.Expect(t, res.RawResponse.ProtoMajor, 2)
+		st.Expect(t, res.String(), "HTTP/2.0")
+	}
+
+	// The given TLS config is left untouched
+	st.Expect(t, len(config.NextProtos), 0)
+}
+
+func TestEnableTransportIsolation(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	Enable(Options{}).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+
+	transport := ctx.Client.Transport.(*http.Transport)
+	st.Expect(t, transport != http.DefaultTransport, true)
+	st.Expect(t, transport.TLSNextProto["h2"] != nil, true)
+}
+
+func TestCleartext(t *testing.T) {
+	ts := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}), &http2.Server{}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.URL(ts.URL)
+	cli.Use(Cleartext(Options{ReadIdleTimeout: time.Second, PingTimeout: time.Second}))
+
+	for i := 0; i < 2; i++ {
+		res, err := cli.Request().Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 200)
+		st.Expect(t, res.RawResponse.ProtoMajor, 2)
+		st.Expect(t, res.String(), "HTTP/2.0")
+	}
+}
+
+func TestCleartextOptions(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	opts := Options{
+		ReadIdleTimeout:            10 * time.Second,
+		PingTimeout:                5 * time.Second,
+		WriteByteTimeout:           time.Second,
+		StrictMaxConcurrentStreams: true,
+	}
+	Cleartext(opts).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+
+	transport := ctx.Client.Transport.(*cleartext)
+	st.Expect(t, transport.h2c.AllowHTTP, true)
+	st.Expect(t, transport.h2c.ReadIdleTimeout, 10*time.Second)
+	st.Expect(t, transport.h2c.PingTimeout, 5*time.Second)
+	st.Expect(t, transport.h2c.WriteByteTimeout, time.Second)
+	st.Expect(t, transport.h2c.StrictMaxConcurrentStreams, true)
+	st.Expect(t, transport.tls != http.DefaultTransport, true)
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
@@ -0,0 +1,378 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	g "gopkg.in/h2non/gentleman.v2"
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+// MaxBodySize defines the default maximum body size in bytes recorded per entry.
+var MaxBodySize int64 = 10 * 1024 * 1024
+
+// Options stores the HAR recorder options.
+type Options struct {
+	// MaxBodySize defines the maximum body size in bytes recorded per entry.
+	// Larger bodies are not recorded. Defaults to MaxBodySize.
+	MaxBodySize int64
+}
+
+// exchange stores the state of an in-flight request.
+type exchange struct {
+	once  sync.Once
+	start time.Time
+	body  []byte
+}
+
+// Recorder implements a plugin that records every request/response pair
+// into an in-memory HAR 1.2 log.
+//
+// Requests are tracked since the "before dial" phase until the "response",
+// "error" or "stop" phase, where the entry is recorded.
+type Recorder struct {
+	// Recorder implements the plugin interface.
+	*p.Layer
+
+	opts    Options
+	mtx     sync.Mutex
+	entries []*Entry
+}
+
+// New creates a new HAR recorder plugin based on the given options.
+func New(opts Options) *Recorder {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = MaxBodySize
+	}
+
+	r := &Recorder{Layer: p.New(), opts: opts}
+	r.SetHandlers(p.Handlers{
+		"before dial": r.start,
+		"response":    r.response,
+		"error":       r.error,
+		"stop":        r.stop,
+	})
+	return r
+}
+
+// Log returns a snapshot of the recorded HAR log.
+func (r *Recorder) Log() *Log {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return &Log{
+		Version: "1.2",
+		Creator: Creator{Name: "gentleman", Version: g.Version},
+		Entries: append([]*Entry{}, r.entries...),
+	}
+}
+
+// Reset discards the recorded entries.
+func (r *Recorder) Reset() {
+	r.mtx.Lock()
+	r.entries = nil
+	r.mtx.Unlock()
+}
+
+// WriteTo writes the recorded HAR log as JSON to the given io.Writer.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	buf, err := json.MarshalIndent(map[string]*Log{"log": r.Log()}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(buf, '\n'))
+	return int64(n), err
+}
+
+// WriteFile writes the recorded HAR log as JSON to the given file path.
+func (r *Recorder) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (r *Recorder) start(ctx *c.Context, h c.Handler) {
+	ex := &exchange{start: time.Now()}
+	ex.body = r.requestBody(ctx.Request)
+	ctx.Set(r, ex)
+	h.Next(ctx)
+}
+
+func (r *Recorder) response(ctx *c.Context, h c.Handler) {
+	if ex, ok := ctx.Get(r).(*exchange); ok {
+		r.record(ctx, ex)
+	}
+	h.Next(ctx)
+}
+
+func (r *Recorder) error(ctx *c.Context, h c.Handler) {
+	ex, ok := ctx.Get(r).(*exchange)
+	if !ok || ctx.Error == nil {
+		h.Next(ctx)
+		return
+	}
+
+	defer func() {
+		// An error recovered by the middleware lets the transaction continue
+		if ctx.Error != nil {
+			r.record(ctx, ex)
+		}
+	}()
+	h.Next(ctx)
+}
+
+func (r *Recorder) stop(ctx *c.Context, h c.Handler) {
+	if ex, ok := ctx.Get(r).(*exchange); ok {
+		defer r.record(ctx, ex)
+	}
+	h.Next(ctx)
+}
+
+// record records the entry of the given exchange only once.
+func (r *Recorder) record(ctx *c.Context, ex *exchange) {
+	ex.once.Do(func() {
+		entry := &Entry{
+			StartedDateTime: ex.start.Format(time.RFC3339Nano),
+			Request:         r.entryRequest(ctx.Request, ex.body),
+			Response:        &Response{Cookies: []Cookie{}, Headers: []NameValue{}, HeadersSize: -1, BodySize: -1},
+		}
+
+		if ctx.Error != nil {
+			entry.Error = ctx.Error.Error()
+		} else {
+			entry.Response = r.entryResponse(ctx.Response)
+		}
+
+		entry.Timings, entry.ServerIPAddress = timings(ctx)
+		for _, t := range []float64{entry.Timings.Blocked, entry.Timings.DNS, entry.Timings.Connect,
+			entry.Timings.Send, entry.Timings.Wait, entry.Timings.Receive} {
+			entry.Time += positive(t)
+		}
+
+		r.mtx.Lock()
+		r.entries = append(r.entries, entry)
+		r.mtx.Unlock()
+	})
+}
+
+// entryRequest creates the HAR request of the given http.Request.
+func (r *Recorder) entryRequest(req *http.Request, body []byte) *Request {
+	har := &Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     cookies(req.Cookies()),
+		Headers:     headers(req.Header),
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+	if har.HTTPVersion == "" {
+		har.HTTPVersion = "HTTP/1.1"
+	}
+
+	query := req.URL.Query()
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			har.QueryString = append(har.QueryString, NameValue{Name: name, Value: value})
+		}
+	}
+
+	if body != nil {
+		har.BodySize = int64(len(body))
+		har.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Params: []NameValue{}}
+		har.PostData.Text, har.PostData.Encoding = encode(body)
+
+		if mediaType, _, _ := mime.ParseMediaType(har.PostData.MimeType); mediaType == "application/x-www-form-urlencoded" {
+			if values, err := url.ParseQuery(string(body)); err == nil {
+				for _, name := range sortedKeys(values) {
+					for _, value := range values[name] {
+						har.PostData.Params = append(har.PostData.Params, NameValue{Name: name, Value: value})
+					}
+				}
+			}
+		}
+	}
+
+	return har
+}
+
+// entryResponse creates the HAR response of the given http.Response,
+// buffering its body, which is replaced so it can still be consumed.
+func (r *Recorder) entryResponse(res *http.Response) *Response {
+	har := &Response{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode))),
+		HTTPVersion: res.Proto,
+		Cookies:     cookies(res.Cookies()),
+		Headers:     headers(res.Header),
+		Content:     Content{Size: res.ContentLength, MimeType: res.Header.Get("Content-Type")},
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    res.ContentLength,
+	}
+	if har.StatusText == "" {
+		har.StatusText = http.StatusText(res.StatusCode)
+	}
+
+	if res.Body == nil || res.Body == http.NoBody {
+		har.Content.Size, har.BodySize = 0, 0
+		return har
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, r.opts.MaxBodySize+1))
+	if err != nil || int64(len(body)) > r.opts.MaxBodySize {
+		res.Body = &reader{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+		har.Content.Comment = "body not recorded: exceeds the maximum size"
+		return har
+	}
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	har.Content.Size, har.BodySize = int64(len(body)), int64(len(body))
+	har.Content.Text, har.Content.Encoding = encode(body)
+	return har
+}
+
+// requestBody reads the request body, preserving it.
+func (r *Recorder) requestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	rc := req.Body
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		defer body.Close()
+		rc = body
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(rc, r.opts.MaxBodySize+1))
+	if req.GetBody == nil {
+		req.Body = &reader{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+	}
+	if err != nil || int64(len(body)) > r.opts.MaxBodySize {
+		return nil
+	}
+	return body
+}
+
+// encode returns the text representation of the given body,
+// using base64 encoding for binary data.
+func encode(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func headers(header http.Header) []NameValue {
+	list := []NameValue{}
+	for _, name := range sortedKeys(header) {
+		for _, value := range header[name] {
+			list = append(list, NameValue{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+func cookies(list []*http.Cookie) []Cookie {
+	har := []Cookie{}
+	for _, cookie := range list {
+		c := Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		har = append(har, c)
+	}
+	return har
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reader joins an io.Reader with the io.Closer of the original body.
+type reader struct {
+	io.Reader
+	io.Closer
+}
+
+// timings returns the HAR timings and the server IP address,
+// based on the transaction timings collected by gentleman.
+func timings(ctx *c.Context) (*Timings, string) {
+	t, ok := ctx.Get("$timings").(*g.Timings)
+	if !ok {
+		t = &g.Timings{}
+	}
+
+	timings := &Timings{
+		Blocked: millis(t.Blocked),
+		DNS:     millis(t.DNSLookup),
+		Connect: millis(t.Connect),
+		SSL:     millis(t.TLSHandshake),
+		Send:    millis(t.Send),
+		Wait:    millis(t.Wait),
+		Receive: millis(t.BodyRead),
+	}
+
+	// Connect time includes the TLS handshake, as defined by the spec
+	if timings.SSL >= 0 && timings.Connect >= 0 {
+		timings.Connect += timings.SSL
+	}
+
+	host := t.RemoteAddr
+	if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		host = strings.Trim(host[:i], "[]")
+	}
+	return timings, host
+}
+
+// millis returns the given duration in milliseconds, or -1 if not applicable.
+func millis(d time.Duration) float64 {
+	if d <= 0 {
+		return -1
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func positive(t float64) float64 {
+	if t < 0 {
+		return 0
+	}
+	return t
+}
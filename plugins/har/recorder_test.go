@@ -0,0 +1,139 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+func TestRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "foo", HttpOnly: true})
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(201)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	rec := New(Options{})
+	cli := gentleman.New()
+	cli.Use(rec)
+
+	res, err := cli.Post().URL(ts.URL + "/foo?b=2&a=1").
+		AddCookie(&http.Cookie{Name: "user", Value: "bar"}).
+		Type("urlencoded").
+		BodyString("foo=bar&baz=1").
+		Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "foo=bar&baz=1")
+
+	log := rec.Log()
+	st.Expect(t, log.Version, "1.2")
+	st.Expect(t, log.Creator, Creator{Name: "gentleman", Version: gentleman.Version})
+	st.Expect(t, len(log.Entries), 1)
+
+	entry := log.Entries[0]
+	st.Expect(t, entry.Error, "")
+	st.Expect(t, entry.ServerIPAddress, "127.0.0.1")
+	st.Expect(t, entry.Time > 0, true)
+	st.Expect(t, entry.Timings.Connect >= 0, true)
+	st.Expect(t, entry.Timings.Send > 0, true)
+	st.Expect(t, entry.Timings.Wait > 0, true)
+	st.Expect(t, entry.Timings.Receive > 0, true)
+	st.Expect(t, entry.Timings.SSL, float64(-1))
+
+	req := entry.Request
+	st.Expect(t, req.Method, "POST")
+	st.Expect(t, req.URL, ts.URL+"/foo?b=2&a=1")
+	st.Expect(t, req.QueryString, []NameValue{{"a", "1"}, {"b", "2"}})
+	st.Expect(t, req.Cookies, []Cookie{{Name: "user", Value: "bar"}})
+	st.Expect(t, req.BodySize, int64(13))
+	st.Expect(t, req.PostData.MimeType, "application/x-www-form-urlencoded")
+	st.Expect(t, req.PostData.Text, "foo=bar&baz=1")
+	st.Expect(t, req.PostData.Params, []NameValue{{"baz", "1"}, {"foo", "bar"}})
+
+	resp := entry.Response
+	st.Expect(t, resp.Status, 201)
+	st.Expect(t, resp.StatusText, "Created")
+	st.Expect(t, resp.HTTPVersion, "HTTP/1.1")
+	st.Expect(t, resp.Cookies, []Cookie{{Name: "session", Value: "foo", HTTPOnly: true}})
+	st.Expect(t, resp.Content.MimeType, "text/plain")
+	st.Expect(t, resp.Content.Text, "foo=bar&baz=1")
+	st.Expect(t, resp.Content.Size, int64(13))
+}
+
+func TestRecorderBinary(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0xff, 0xfe, 0x00})
+	}))
+	defer ts.Close()
+
+	rec := New(Options{})
+	cli := gentleman.New()
+	cli.Use(rec)
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.Bytes(), []byte{0xff, 0xfe, 0x00})
+
+	content := rec.Log().Entries[0].Response.Content
+	st.Expect(t, content.Encoding, "base64")
+	st.Expect(t, content.Text, "//4A")
+}
+
+func TestRecorderError(t *testing.T) {
+	rec := New(Options{})
+	cli := gentleman.New()
+	cli.Use(rec)
+
+	_, err := cli.Request().URL("http://127.0.0.1:9123").Send()
+	st.Reject(t, err, nil)
+
+	entry := rec.Log().Entries[0]
+	st.Expect(t, entry.Error, err.Error())
+	st.Expect(t, entry.Response.Status, 0)
+	st.Expect(t, entry.Timings.Wait, float64(-1))
+}
+
+func TestRecorderWrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	rec := New(Options{})
+	cli := gentleman.New()
+	cli.Use(rec)
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+
+	buf := &bytes.Buffer{}
+	n, err := rec.WriteTo(buf)
+	st.Expect(t, err, nil)
+	st.Expect(t, n, int64(buf.Len()))
+
+	var har struct {
+		Log *Log `json:"log"`
+	}
+	st.Expect(t, json.Unmarshal(buf.Bytes(), &har), nil)
+	st.Expect(t, len(har.Log.Entries), 1)
+
+	dir, err := ioutil.TempDir("", "gentleman-har")
+	st.Expect(t, err, nil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture.har")
+	st.Expect(t, rec.WriteFile(path), nil)
+	data, err := ioutil.ReadFile(path)
+	st.Expect(t, err, nil)
+	st.Expect(t, data, buf.Bytes())
+
+	rec.Reset()
+	st.Expect(t, len(rec.Log().Entries), 0)
+}
@@ -0,0 +1,260 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/plugins/redirect"
+)
+
+var (
+	// Message defines the default log record message.
+	Message = "http request"
+
+	// MaxBodySize defines the default maximum number of body bytes captured.
+	MaxBodySize = 4096
+)
+
+// Options stores the logging options.
+type Options struct {
+	// Logger defines the logger used to emit records. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Level defines the level of records of successful requests. Defaults to slog.LevelInfo.
+	// Requests failed with an error are always logged with slog.LevelError.
+	Level slog.Level
+
+	// Message defines the log record message. Defaults to Message.
+	Message string
+
+	// RequestHeaders enables capturing the request header fields.
+	RequestHeaders bool
+
+	// ResponseHeaders enables capturing the response header fields.
+	ResponseHeaders bool
+
+	// RequestBody enables capturing the request body.
+	RequestBody bool
+
+	// ResponseBody enables capturing the response body.
+	ResponseBody bool
+
+	// MaxBodySize defines the maximum number of body bytes captured. Defaults to MaxBodySize.
+	MaxBodySize int
+
+	// RedactHeaders defines the header fields whose values are redacted.
+	// Defaults to redirect.SensitiveHeaders plus Cookie and Set-Cookie.
+	RedactHeaders []string
+
+	// RedactFields defines the JSON body fields whose values are redacted, at any depth.
+	RedactFields []string
+}
+
+// request stores the logging state of an in-flight request.
+type request struct {
+	once  sync.Once
+	start time.Time
+	body  []byte
+}
+
+// Logging implements a plugin that emits one structured log record per request.
+//
+// Requests are tracked since the "before dial" phase until the "response",
+// "error" or "stop" phase, where the record is emitted.
+type Logging struct {
+	// Logging implements the plugin interface.
+	*p.Layer
+
+	opts     Options
+	redactor *redactor
+}
+
+// New creates a new logging plugin based on the given options.
+func New(opts Options) *Logging {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Message == "" {
+		opts.Message = Message
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = MaxBodySize
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = append(append([]string(nil), redirect.SensitiveHeaders...), "Cookie", "Set-Cookie")
+	}
+
+	l := &Logging{Layer: p.New(), opts: opts, redactor: newRedactor(opts.RedactHeaders, opts.RedactFields)}
+	l.SetHandlers(p.Handlers{
+		"before dial": l.start,
+		"response":    l.response,
+		"error":       l.error,
+		"stop":        l.stop,
+	})
+	return l
+}
+
+func (l *Logging) start(ctx *c.Context, h c.Handler) {
+	req := &request{start: time.Now()}
+	if l.opts.RequestBody {
+		req.body = l.requestBody(ctx.Request)
+	}
+	ctx.Set(l, req)
+	h.Next(ctx)
+}
+
+func (l *Logging) response(ctx *c.Context, h c.Handler) {
+	if req, ok := ctx.Get(l).(*request); ok {
+		l.log(ctx, req)
+	}
+	h.Next(ctx)
+}
+
+func (l *Logging) error(ctx *c.Context, h c.Handler) {
+	req, ok := ctx.Get(l).(*request)
+	if !ok || ctx.Error == nil {
+		h.Next(ctx)
+		return
+	}
+
+	defer func() {
+		// An error recovered by the middleware lets the transaction continue
+		if ctx.Error != nil {
+			l.log(ctx, req)
+		}
+	}()
+	h.Next(ctx)
+}
+
+func (l *Logging) stop(ctx *c.Context, h c.Handler) {
+	if req, ok := ctx.Get(l).(*request); ok {
+		defer l.log(ctx, req)
+	}
+	h.Next(ctx)
+}
+
+// log emits the log record of the given request only once.
+func (l *Logging) log(ctx *c.Context, req *request) {
+	req.once.Do(func() {
+		r, res := ctx.Request, ctx.Response
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("url", r.URL.Redacted()),
+			slog.Duration("duration", time.Since(req.start)),
+			slog.Int64("request_size", r.ContentLength),
+		}
+
+		level := l.opts.Level
+		if ctx.Error != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", ctx.Error.Error()))
+		} else {
+			attrs = append(attrs, slog.Int("status", res.StatusCode), slog.Int64("response_size", res.ContentLength))
+		}
+
+		if l.opts.RequestHeaders {
+			attrs = append(attrs, l.headers("request_headers", r.Header))
+		}
+		if l.opts.RequestBody && req.body != nil {
+			attrs = append(attrs, slog.String("request_body", l.body(req.body)))
+		}
+		if ctx.Error == nil && l.opts.ResponseHeaders {
+			attrs = append(attrs, l.headers("response_headers", res.Header))
+		}
+		if ctx.Error == nil && l.opts.ResponseBody {
+			if body := l.responseBody(res); body != nil {
+				attrs = append(attrs, slog.String("response_body", l.body(body)))
+			}
+		}
+
+		l.opts.Logger.LogAttrs(ctx, level, l.opts.Message, attrs...)
+	})
+}
+
+// headers returns a group attribute with the redacted header fields.
+func (l *Logging) headers(key string, header http.Header) slog.Attr {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, slog.String(name, l.redactor.header(name, header[name])))
+	}
+	return slog.Group(key, attrs...)
+}
+
+// body returns the printable representation of the given captured body,
+// which may exceed the maximum size by one byte to flag it as truncated.
+func (l *Logging) body(body []byte) string {
+	truncated := len(body) > l.opts.MaxBodySize
+	if truncated {
+		body = body[:l.opts.MaxBodySize]
+		// Discard a trailing incomplete UTF-8 sequence, if any
+		for i := 0; i < utf8.UTFMax && len(body) > 0 && !utf8.Valid(body); i++ {
+			body = body[:len(body)-1]
+		}
+	}
+	if !utf8.Valid(body) {
+		return "[binary]"
+	}
+
+	body = l.redactor.body(body, truncated)
+	if truncated {
+		return string(body) + "...[truncated]"
+	}
+	return string(body)
+}
+
+// requestBody captures the request body, preserving it.
+func (l *Logging) requestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		defer body.Close()
+		return l.capture(body)
+	}
+
+	buf := l.capture(req.Body)
+	req.Body = &reader{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+	return buf
+}
+
+// responseBody captures the response body, preserving it.
+func (l *Logging) responseBody(res *http.Response) []byte {
+	if res.Body == nil || res.Body == http.NoBody {
+		return nil
+	}
+	buf := l.capture(res.Body)
+	res.Body = &reader{Reader: io.MultiReader(bytes.NewReader(buf), res.Body), Closer: res.Body}
+	return buf
+}
+
+// capture reads up to the maximum body size plus one byte from the given reader,
+// so truncated bodies can be detected.
+func (l *Logging) capture(r io.Reader) []byte {
+	buf, _ := ioutil.ReadAll(io.LimitReader(r, int64(l.opts.MaxBodySize)+1))
+	return buf
+}
+
+// reader joins an io.Reader with the io.Closer of the original body.
+type reader struct {
+	io.Reader
+	io.Closer
+}
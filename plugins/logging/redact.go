@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Redacted defines the value used to replace redacted header and body field values.
+var Redacted = "[REDACTED]"
+
+// redactor redacts sensitive header and JSON body field values.
+type redactor struct {
+	headers map[string]bool
+	fields  map[string]bool
+	pattern *regexp.Regexp
+}
+
+func newRedactor(headers, fields []string) *redactor {
+	r := &redactor{headers: make(map[string]bool), fields: make(map[string]bool)}
+	for _, name := range headers {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, name := range fields {
+		r.fields[strings.ToLower(name)] = true
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	if len(names) > 0 {
+		// Matches string (even if truncated) or scalar values of the given fields
+		r.pattern = regexp.MustCompile(`(?i)("(?:` + strings.Join(names, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^\s,}\]]+)`)
+	}
+	return r
+}
+
+// header returns the header field values, redacting them if required.
+func (r *redactor) header(name string, values []string) string {
+	if r.headers[http.CanonicalHeaderKey(name)] {
+		return Redacted
+	}
+	return strings.Join(values, ", ")
+}
+
+// body redacts the JSON fields of the given body. Bodies that cannot
+// be decoded, such as truncated ones, are redacted on a best-effort basis.
+func (r *redactor) body(body []byte, truncated bool) []byte {
+	if len(r.fields) == 0 {
+		return body
+	}
+
+	if !truncated {
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err == nil {
+			if buf, err := json.Marshal(r.value(data)); err == nil {
+				return buf
+			}
+		}
+	}
+
+	return r.pattern.ReplaceAll(body, []byte(`${1}"`+Redacted+`"`))
+}
+
+// value redacts the matching fields of the given decoded JSON value recursively.
+func (r *redactor) value(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = r.value(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.value(value)
+		}
+	}
+	return data
+}
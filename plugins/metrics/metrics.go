@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/plugins/url"
+)
+
+var (
+	// DurationBuckets defines the default request duration histogram buckets, in seconds.
+	DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// SizeBuckets defines the default response size histogram buckets, in bytes.
+	SizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// RouteFunc returns the route label value for the given request context.
+type RouteFunc func(*c.Context) string
+
+// Template is the default RouteFunc, which uses the URL path template
+// defined via Request.Path and friends, before path params are replaced.
+func Template(ctx *c.Context) string {
+	return url.Template(ctx)
+}
+
+// Options stores the metrics options.
+type Options struct {
+	// Namespace defines an optional prefix for the metric names.
+	Namespace string
+
+	// DurationBuckets defines the request duration histogram buckets, in seconds.
+	// Defaults to DurationBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets defines the response size histogram buckets, in bytes.
+	// Defaults to SizeBuckets.
+	SizeBuckets []float64
+
+	// Route defines the function used to compute the route label. Defaults to Template.
+	Route RouteFunc
+}
+
+// request stores the metrics state of an in-flight request.
+type request struct {
+	once   sync.Once
+	start  time.Time
+	labels []string
+}
+
+// Metrics implements a plugin that records client request metrics,
+// exposed in the Prometheus text format via Handler.
+//
+// Requests are labeled by method, host, route and status class, where
+// network errors use the "error" status class. Requests are tracked since
+// the "before dial" phase until the "response", "error" or "stop" phase.
+// Response sizes are recorded once the response body is fully read or closed.
+type Metrics struct {
+	// Metrics implements the plugin interface.
+	*p.Layer
+
+	opts     Options
+	registry *registry
+	requests *family
+	inflight *family
+	duration *family
+	size     *family
+}
+
+// New creates a new metrics plugin based on the given options.
+func New(opts Options) *Metrics {
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = DurationBuckets
+	}
+	if opts.SizeBuckets == nil {
+		opts.SizeBuckets = SizeBuckets
+	}
+	if opts.Route == nil {
+		opts.Route = Template
+	}
+	opts.DurationBuckets = sorted(opts.DurationBuckets)
+	opts.SizeBuckets = sorted(opts.SizeBuckets)
+
+	prefix := ""
+	if opts.Namespace != "" {
+		prefix = opts.Namespace + "_"
+	}
+	labels := []string{"method", "host", "route"}
+	status := []string{"method", "host", "route", "status"}
+
+	m := &Metrics{
+		Layer: p.New(),
+		opts:  opts,
+		requests: newFamily(prefix+"http_client_requests_total",
+			"Total number of HTTP client requests.", counter, status, nil),
+		inflight: newFamily(prefix+"http_client_requests_in_flight",
+			"Number of in-flight HTTP client requests.", gauge, labels, nil),
+		duration: newFamily(prefix+"http_client_request_duration_seconds",
+			"HTTP client request duration until response headers are received, in seconds.", histogram, status, opts.DurationBuckets),
+		size: newFamily(prefix+"http_client_response_size_bytes",
+			"HTTP client response body size, in bytes.", histogram, status, opts.SizeBuckets),
+	}
+	m.registry = &registry{families: []*family{m.requests, m.inflight, m.duration, m.size}}
+
+	m.SetHandlers(p.Handlers{
+		"before dial": m.start,
+		"response":    m.response,
+		"error":       m.error,
+		"stop":        m.stop,
+	})
+	return m
+}
+
+// Handler returns an http.Handler that exposes the recorded metrics
+// in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// WriteTo writes the recorded metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	return m.registry.WriteTo(w)
+}
+
+func (m *Metrics) start(ctx *c.Context, h c.Handler) {
+	// Requests built with no network dialing, such as by Request.Build, are not recorded
+	if ctx.Get("$dryrun") == true {
+		h.Next(ctx)
+		return
+	}
+
+	req := &request{
+		start:  time.Now(),
+		labels: []string{ctx.Request.Method, ctx.Request.URL.Host, m.opts.Route(ctx)},
+	}
+	m.inflight.add(1, req.labels...)
+	ctx.Set(m, req)
+	h.Next(ctx)
+}
+
+// finish records the metrics of the given request only once.
+func (m *Metrics) finish(ctx *c.Context, req *request, status string) {
+	req.once.Do(func() {
+		m.inflight.add(-1, req.labels...)
+		labels := append(append([]string(nil), req.labels...), status)
+		m.requests.add(1, labels...)
+		m.duration.observe(time.Since(req.start).Seconds(), labels...)
+
+		if status == "error" {
+			return
+		}
+		res := ctx.Response
+		if res.ContentLength >= 0 {
+			m.size.observe(float64(res.ContentLength), labels...)
+			return
+		}
+		res.Body = &sizeBody{ReadCloser: res.Body, observe: func(n int64) {
+			m.size.observe(float64(n), labels...)
+		}}
+	})
+}
+
+func (m *Metrics) response(ctx *c.Context, h c.Handler) {
+	if req, ok := ctx.Get(m).(*request); ok {
+		m.finish(ctx, req, statusClass(ctx.Response.StatusCode))
+	}
+	h.Next(ctx)
+}
+
+func (m *Metrics) error(ctx *c.Context, h c.Handler) {
+	req, ok := ctx.Get(m).(*request)
+	if !ok || ctx.Error == nil {
+		h.Next(ctx)
+		return
+	}
+
+	defer func() {
+		// An error recovered by the middleware lets the transaction continue
+		if ctx.Error != nil {
+			m.finish(ctx, req, "error")
+		}
+	}()
+	h.Next(ctx)
+}
+
+func (m *Metrics) stop(ctx *c.Context, h c.Handler) {
+	if req, ok := ctx.Get(m).(*request); ok {
+		defer m.finish(ctx, req, statusClass(ctx.Response.StatusCode))
+	}
+	h.Next(ctx)
+}
+
+// sorted returns a sorted copy of the given histogram buckets.
+func sorted(buckets []float64) []float64 {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return buckets
+}
+
+// statusClass returns the status class label value of the given status code (e.g: 2xx).
+func statusClass(code int) string {
+	if code < 100 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// sizeBody counts the bytes read from the response body, reporting
+// the total once the body is fully read or closed.
+type sizeBody struct {
+	io.ReadCloser
+	once    sync.Once
+	n       int64
+	observe func(int64)
+}
+
+func (b *sizeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.once.Do(func() { b.observe(b.n) })
+	}
+	return n, err
+}
+
+func (b *sizeBody) Close() error {
+	b.once.Do(func() { b.observe(b.n) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/404") {
+			w.WriteHeader(404)
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	m := New(Options{Namespace: "api", DurationBuckets: []float64{60, 1}})
+	cli := gentleman.New()
+	cli.URL(ts.URL).Use(m)
+
+	for _, id := range []string{"1", "2", "404"} {
+		res, err := cli.Request().Path("/users/:id").Param("id", id).Send()
+		st.Expect(t, err, nil)
+		res.Close()
+	}
+
+	out := metrics(t, m)
+	host := ts.Listener.Addr().String()
+	labels := `method="GET",host="` + host + `",route="/users/:id"`
+	expect(t, out, `# TYPE api_http_client_requests_total counter`)
+	expect(t, out, `api_http_client_requests_total{`+labels+`,status="2xx"} 2`)
+	expect(t, out, `api_http_client_requests_total{`+labels+`,status="4xx"} 1`)
+	expect(t, out, `api_http_client_requests_in_flight{`+labels+`} 0`)
+	expect(t, out, `# TYPE api_http_client_request_duration_seconds histogram`)
+	expect(t, out, `api_http_client_request_duration_seconds_bucket{`+labels+`,status="2xx",le="1"} 2`)
+	expect(t, out, `api_http_client_request_duration_seconds_bucket{`+labels+`,status="2xx",le="60"} 2`)
+	expect(t, out, `api_http_client_request_duration_seconds_bucket{`+labels+`,status="2xx",le="+Inf"} 2`)
+	expect(t, out, `api_http_client_request_duration_seconds_count{`+labels+`,status="2xx"} 2`)
+	expect(t, out, `api_http_client_response_size_bytes_bucket{`+labels+`,status="2xx",le="100"} 2`)
+	expect(t, out, `api_http_client_response_size_bytes_sum{`+labels+`,status="2xx"} 22`)
+	expect(t, out, `api_http_client_response_size_bytes_sum{`+labels+`,status="4xx"} 0`)
+}
+
+func TestMetricsError(t *testing.T) {
+	m := New(Options{})
+	cli := gentleman.New()
+	cli.Use(m)
+
+	_, err := cli.Request().URL("http://127.0.0.1:9123/foo").Send()
+	st.Reject(t, err, nil)
+
+	out := metrics(t, m)
+	labels := `method="GET",host="127.0.0.1:9123",route="/foo"`
+	expect(t, out, `http_client_requests_total{`+labels+`,status="error"} 1`)
+	expect(t, out, `http_client_requests_in_flight{`+labels+`} 0`)
+	st.Expect(t, strings.Contains(out, "http_client_response_size_bytes"), false)
+}
+
+func TestMetricsBuild(t *testing.T) {
+	m := New(Options{})
+	cli := gentleman.New()
+	cli.Use(m)
+
+	// Requests built with no network dialing are not recorded
+	_, err := cli.Request().URL("http://127.0.0.1:9123/foo").Build()
+	st.Expect(t, err, nil)
+	st.Expect(t, strings.Contains(metrics(t, m), "http_client_requests"), false)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	m := New(Options{})
+	m.requests.add(1, "GET", "foo.com", `/"quoted"`, "2xx")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	st.Expect(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+	st.Expect(t, rec.Body.String(), "# HELP http_client_requests_total Total number of HTTP client requests.\n"+
+		"# TYPE http_client_requests_total counter\n"+
+		`http_client_requests_total{method="GET",host="foo.com",route="/\"quoted\"",status="2xx"} 1`+"\n")
+}
+
+func TestSizeBody(t *testing.T) {
+	var size int64 = -1
+	body := &sizeBody{ReadCloser: ioutil.NopCloser(bytes.NewBufferString("hello")), observe: func(n int64) { size = n }}
+	buf, _ := ioutil.ReadAll(bufio.NewReader(body))
+	st.Expect(t, string(buf), "hello")
+	st.Expect(t, size, int64(5))
+	body.Close()
+	st.Expect(t, size, int64(5))
+}
+
+func metrics(t *testing.T, m *Metrics) string {
+	buf := &bytes.Buffer{}
+	_, err := m.WriteTo(buf)
+	st.Expect(t, err, nil)
+	return buf.String()
+}
+
+func expect(t *testing.T, out, line string) {
+	if !strings.Contains(out, line+"\n") {
+		t.Errorf("missing metric line: %s\n%s", line, out)
+	}
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types supported by the Prometheus text exposition format.
+const (
+	counter   = "counter"
+	gauge     = "gauge"
+	histogram = "histogram"
+)
+
+// series stores the values of a metric for a set of label values.
+type series struct {
+	values []string
+	value  float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// family stores a metric and all its labeled series.
+type family struct {
+	mtx     sync.Mutex
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+func newFamily(name, help, kind string, labels []string, buckets []float64) *family {
+	return &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*series)}
+}
+
+// get returns the series for the given label values, creating it if required.
+// Must be called with the lock held.
+func (f *family) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: values}
+		if f.kind == histogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// add adds the given delta to the counter or gauge series.
+func (f *family) add(delta float64, values ...string) {
+	f.mtx.Lock()
+	f.get(values).value += delta
+	f.mtx.Unlock()
+}
+
+// observe records the given value in the histogram series.
+func (f *family) observe(value float64, values ...string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	s := f.get(values)
+	if i := sort.SearchFloat64s(f.buckets, value); i < len(f.buckets) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+}
+
+// write writes the metric family in the Prometheus text exposition format.
+func (f *family) write(w *bufio.Writer) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if len(f.series) == 0 {
+		return
+	}
+
+	w.WriteString("# HELP " + f.name + " " + f.help + "\n")
+	w.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != histogram {
+			f.sample(w, "", s.values, "", "", s.value)
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			f.sample(w, "_bucket", s.values, "le", formatFloat(bound), float64(cumulative))
+		}
+		f.sample(w, "_bucket", s.values, "le", "+Inf", float64(s.count))
+		f.sample(w, "_sum", s.values, "", "", s.sum)
+		f.sample(w, "_count", s.values, "", "", float64(s.count))
+	}
+}
+
+// sample writes a single sample line, with an optional extra label.
+func (f *family) sample(w *bufio.Writer, suffix string, values []string, extra, extraValue string, value float64) {
+	w.WriteString(f.name + suffix)
+	if len(f.labels) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, label := range f.labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label + `="` + escape(values[i]) + `"`)
+		}
+		if extra != "" {
+			if len(f.labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// registry stores the metric families exposed by a Metrics plugin.
+type registry struct {
+	families []*family
+}
+
+// WriteTo writes all the metric families in the Prometheus text exposition format.
+func (r *registry) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	buf := bufio.NewWriter(cw)
+	for _, f := range r.families {
+		f.write(buf)
+	}
+	err := buf.Flush()
+	return cw.n, err
+}
+
+// countWriter counts the bytes written to the underlying io.Writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -8,6 +8,7 @@ import (
 	"mime/multipart"
 	"strconv"
 	"strings"
+	"sync"
 
 	c "gopkg.in/h2non/gentleman.v2/context"
 	p "gopkg.in/h2non/gentleman.v2/plugin"
@@ -21,8 +22,14 @@ type DataFields map[string]Values
 
 // FormFile represents the file form field data.
 type FormFile struct {
-	Name   string
+	// Name defines the form field name, also used as file name by default.
+	Name string
+
+	// Reader defines the file content stream.
 	Reader io.Reader
+
+	// FileName optionally defines the file name sent to the server, if different from Name.
+	FileName string
 }
 
 // FormData represents the supported form fields by file and string data.
@@ -34,52 +41,63 @@ type FormData struct {
 // File creates a new multipart form based on a unique file field
 // from the given io.ReadCloser stream.
 func File(name string, reader io.Reader) p.Plugin {
-	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
-		file := FormFile{name, reader}
-		data := FormData{Files: []FormFile{file}}
-		handle(ctx, h, data)
-	})
+	file := FormFile{Name: name, Reader: reader}
+	return form(FormData{Files: []FormFile{file}})
 }
 
 // Files creates a multipart form based on files fields.
 func Files(files []FormFile) p.Plugin {
-	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
-		data := FormData{Files: files}
-		handle(ctx, h, data)
-	})
+	return form(FormData{Files: files})
 }
 
 // Fields creates a new multipart form based on string based fields.
 func Fields(fields DataFields) p.Plugin {
-	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
-		data := FormData{Data: fields}
-		handle(ctx, h, data)
-	})
+	return form(FormData{Data: fields})
 }
 
 // Data creates custom form based on the given form data
 // who can have files and string based fields.
 func Data(data FormData) p.Plugin {
-	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
-		handle(ctx, h, data)
-	})
+	return form(data)
 }
 
-func handle(ctx *c.Context, h c.Handler, data FormData) {
-	if err := createForm(data, ctx); err != nil {
-		h.Error(ctx, err)
-		return
-	}
-	h.Next(ctx)
+// form creates a plugin defining the given form data as request body.
+// The file readers are consumed only once, on first use, snapshotting the encoded form,
+// so the body is re-created on every dispatch.
+func form(data FormData) p.Plugin {
+	var once sync.Once
+	var body []byte
+	var contentType string
+	var err error
+
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		once.Do(func() {
+			body, contentType, err = createForm(data)
+		})
+		if err != nil {
+			h.Error(ctx, err)
+			return
+		}
+
+		ctx.Request.Method = setMethod(ctx)
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		ctx.Request.ContentLength = int64(len(body))
+		ctx.Request.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		ctx.Request.Header.Add("Content-Type", contentType)
+		h.Next(ctx)
+	})
 }
 
-func createForm(data FormData, ctx *c.Context) error {
+// createForm encodes the given form data, returning the body and its content type.
+func createForm(data FormData) ([]byte, string, error) {
 	body := &bytes.Buffer{}
 	multipartWriter := multipart.NewWriter(body)
 
 	for index, file := range data.Files {
 		if err := writeFile(multipartWriter, data, file, index); err != nil {
-			return err
+			return nil, "", err
 		}
 	}
 
@@ -90,14 +108,10 @@ func createForm(data FormData, ctx *c.Context) error {
 		}
 	}
 	if err := multipartWriter.Close(); err != nil {
-		return err
+		return nil, "", err
 	}
 
-	ctx.Request.Method = setMethod(ctx)
-	ctx.Request.Body = ioutil.NopCloser(body)
-	ctx.Request.Header.Add("Content-Type", multipartWriter.FormDataContentType())
-
-	return nil
+	return body.Bytes(), multipartWriter.FormDataContentType(), nil
 }
 
 func writeFile(multipartWriter *multipart.Writer, data FormData, file FormFile, index int) error {
@@ -118,7 +132,12 @@ func writeFile(multipartWriter *multipart.Writer, data FormData, file FormFile,
 		fileName = file.Name
 	}
 
-	writer, err := multipartWriter.CreateFormFile(fileName, file.Name)
+	name := file.Name
+	if file.FileName != "" {
+		name = file.FileName
+	}
+
+	writer, err := multipartWriter.CreateFormFile(fileName, name)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/nbio/st"
 	"gopkg.in/h2non/gentleman.v2/context"
+	"io"
 	"io/ioutil"
 	"strings"
 	"testing"
@@ -28,8 +29,8 @@ func TestFiles(t *testing.T) {
 	fn := newHandler()
 	reader1 := bytes.NewReader([]byte("content1"))
 	reader2 := bytes.NewReader([]byte("content2"))
-	file1 := FormFile{"file1", reader1}
-	file2 := FormFile{"file2", reader2}
+	file1 := FormFile{Name: "file1", Reader: reader1}
+	file2 := FormFile{Name: "file2", Reader: reader2}
 
 	Files([]FormFile{file1, file2}).Exec("request", ctx, fn.fn)
 	st.Expect(t, fn.called, true)
@@ -42,6 +43,36 @@ func TestFiles(t *testing.T) {
 	st.Expect(t, match(body, "content2"), true)
 }
 
+func TestFileName(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	file := FormFile{Name: "upload", Reader: strings.NewReader("hello world"), FileName: "data.txt"}
+
+	Files([]FormFile{file}).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+	body, _ := ioutil.ReadAll(ctx.Request.Body)
+	st.Expect(t, match(body, `Content-Disposition: form-data; name="upload"; filename="data.txt"`), true)
+	st.Expect(t, match(body, "hello world"), true)
+}
+
+func TestFileReusable(t *testing.T) {
+	plugin := File("foo", strings.NewReader("hello world"))
+
+	for i := 0; i < 2; i++ {
+		ctx := context.New()
+		fn := newHandler()
+		plugin.Exec("request", ctx, fn.fn)
+		st.Expect(t, fn.called, true)
+
+		body, _ := ioutil.ReadAll(ctx.Request.Body)
+		st.Expect(t, int(ctx.Request.ContentLength), len(body))
+		st.Expect(t, match(body, "hello world"), true)
+
+		body, _ = ioutil.ReadAll(must(ctx.Request.GetBody()))
+		st.Expect(t, match(body, "hello world"), true)
+	}
+}
+
 func TestFields(t *testing.T) {
 	ctx := context.New()
 	fn := newHandler()
@@ -93,3 +124,10 @@ func newHandler() *handler {
 func match(body []byte, str string) bool {
 	return strings.Contains(string(body), str)
 }
+
+func must(body io.ReadCloser, err error) io.ReadCloser {
+	if err != nil {
+		panic(err)
+	}
+	return body
+}
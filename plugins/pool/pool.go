@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"net/http"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
+)
+
+// Options stores the connection pool options.
+// Zero values keep the current transport settings.
+type Options struct {
+	// MaxIdleConns defines the maximum number of idle connections across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost defines the maximum number of idle connections per host.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost defines the maximum number of connections per host,
+	// including connections in the dialing, active and idle states.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout defines the maximum amount of time an idle connection
+	// remains in the pool before closing itself.
+	IdleConnTimeout time.Duration
+
+	// ResponseHeaderTimeout defines the maximum amount of time to wait
+	// for the response headers after fully writing the request.
+	ResponseHeaderTimeout time.Duration
+}
+
+// Config defines the connection pool settings of the HTTP transport based on the given options.
+// The transport is cloned on write, leaving the shared one untouched.
+func Config(opts Options) p.Plugin {
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		// Assert http.Transport to work with the instance
+		transport, ok := ctx.Client.Transport.(*http.Transport)
+		if !ok {
+			// If using a custom transport, just ignore it
+			h.Next(ctx)
+			return
+		}
+
+		ctx.Client.Transport = utils.Transports(ctx).Derive(transport, opts, func(transport *http.Transport) {
+			configure(transport, opts)
+		})
+		h.Next(ctx)
+	})
+}
+
+// configure applies the given pool options to the transport.
+func configure(transport *http.Transport, opts Options) {
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	}
+}
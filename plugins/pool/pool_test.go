@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestConfig(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	Config(Options{
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   5,
+		MaxConnsPerHost:       20,
+		IdleConnTimeout:       time.Minute,
+		ResponseHeaderTimeout: time.Second,
+	}).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+
+	transport := ctx.Client.Transport.(*http.Transport)
+	st.Expect(t, transport.MaxIdleConns, 10)
+	st.Expect(t, transport.MaxIdleConnsPerHost, 5)
+	st.Expect(t, transport.MaxConnsPerHost, 20)
+	st.Expect(t, transport.IdleConnTimeout, time.Minute)
+	st.Expect(t, transport.ResponseHeaderTimeout, time.Second)
+
+	// The shared transport is cloned on write
+	st.Expect(t, transport != http.DefaultTransport, true)
+	st.Reject(t, http.DefaultTransport.(*http.Transport).MaxConnsPerHost, 20)
+}
+
+func TestConfigStats(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	cli := gentleman.New()
+	cli.URL(ts.URL)
+	cli.Use(Config(Options{MaxIdleConnsPerHost: 1, MaxConnsPerHost: 2}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cli.Request().Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.String(), "ok")
+		}()
+	}
+
+	// Only two connections are opened, while the third request waits
+	waitFor(t, func() bool { return cli.Stats()[host].InUse == 2 })
+	st.Expect(t, cli.Stats()[host], gentleman.ConnStats{Open: 2, InUse: 2})
+
+	close(release)
+	wg.Wait()
+
+	// Only one idle connection is kept in the pool
+	waitFor(t, func() bool { return cli.Stats()[host].InUse == 0 })
+	st.Expect(t, cli.Stats()[host], gentleman.ConnStats{Open: 1, Idle: 1})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
@@ -3,12 +3,17 @@ package proxy
 import (
 	c "gopkg.in/h2non/gentleman.v2/context"
 	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 )
 
-// Set defines the proxy servers to be used based on the transport scheme
+// Set defines the proxy servers to be used based on the transport scheme.
+// The transport is cloned on write, leaving the shared one untouched.
 func Set(servers map[string]string) p.Plugin {
+	servers, k := proxies(servers)
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		// Assert http.Transport to work with the instance
 		transport, ok := ctx.Client.Transport.(*http.Transport)
@@ -18,16 +23,30 @@ func Set(servers map[string]string) p.Plugin {
 			return
 		}
 
-		// Define the proxy function to be used during the transport
-		transport.Proxy = func(req *http.Request) (*url.URL, error) {
-			if value, ok := servers[req.URL.Scheme]; ok {
-				return url.Parse(value)
+		// Override the transport, defining the proxy function to be used
+		ctx.Client.Transport = utils.Transports(ctx).Derive(transport, k, func(transport *http.Transport) {
+			transport.Proxy = func(req *http.Request) (*url.URL, error) {
+				if value, ok := servers[req.URL.Scheme]; ok {
+					return url.Parse(value)
+				}
+				return http.ProxyFromEnvironment(req)
 			}
-			return http.ProxyFromEnvironment(req)
-		}
-
-		// Override the transport
-		ctx.Client.Transport = transport
+		})
 		h.Next(ctx)
 	})
 }
+
+// key identifies the transports derived for a set of proxy servers.
+type key string
+
+// proxies returns a copy of the given proxy servers and their key.
+func proxies(servers map[string]string) (map[string]string, key) {
+	proxies := make(map[string]string, len(servers))
+	schemes := make([]string, 0, len(servers))
+	for scheme, server := range servers {
+		proxies[scheme] = server
+		schemes = append(schemes, scheme+"="+server)
+	}
+	sort.Strings(schemes)
+	return proxies, key(strings.Join(schemes, "\n"))
+}
@@ -26,6 +26,9 @@ func TestProxy(t *testing.T) {
 	st.Expect(t, err, nil)
 	st.Expect(t, url.Host, "localhost:3128")
 	st.Expect(t, url.Scheme, "http")
+
+	// The shared transport is cloned on write
+	st.Expect(t, transport != http.DefaultTransport, true)
 }
 
 func TestProxyParseError(t *testing.T) {
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	gocontext "context"
+	"sync"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+// KeyFunc returns the rate limit bucket key for the given request context.
+type KeyFunc func(*c.Context) string
+
+// Host is the default KeyFunc, which limits requests per URL host.
+func Host(ctx *c.Context) string {
+	return ctx.Request.URL.Host
+}
+
+// Global is a KeyFunc that shares a unique bucket across all the requests.
+func Global(ctx *c.Context) string {
+	return ""
+}
+
+// Options stores the rate limiter options.
+type Options struct {
+	// Rate defines the number of requests per second allowed per bucket.
+	// A zero value disables the rate limit.
+	Rate float64
+
+	// Burst defines the maximum number of requests that can be performed at once.
+	// Defaults to 1.
+	Burst int
+
+	// Key defines the function used to compute the bucket key. Defaults to Host.
+	Key KeyFunc
+}
+
+// New creates a new rate limiter plugin based on the given options.
+// Outgoing requests are blocked in the "before dial" phase until a token
+// is available in its bucket. If the request cancel context is done
+// while waiting, the request fails with the context error.
+func New(opts Options) p.Plugin {
+	limiter := NewLimiter(opts)
+	return p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		// Requests built with no network dialing, such as by Request.Build, take no tokens
+		if ctx.Get("$dryrun") == true {
+			h.Next(ctx)
+			return
+		}
+		if err := limiter.Wait(ctx, limiter.opts.Key(ctx)); err != nil {
+			h.Error(ctx, err)
+			return
+		}
+		h.Next(ctx)
+	})
+}
+
+// PerHost creates a new rate limiter plugin allowing the given number
+// of requests per second and burst per URL host.
+func PerHost(rate float64, burst int) p.Plugin {
+	return New(Options{Rate: rate, Burst: burst})
+}
+
+// Limiter implements a token bucket rate limiter with per key buckets.
+type Limiter struct {
+	opts    Options
+	mtx     sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewLimiter creates a new token bucket Limiter based on the given options.
+func NewLimiter(opts Options) *Limiter {
+	if opts.Burst <= 0 {
+		opts.Burst = 1
+	}
+	if opts.Key == nil {
+		opts.Key = Host
+	}
+	return &Limiter{opts: opts, buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Wait blocks until a token is available in the bucket identified by the given key,
+// or returns the context error if the context is done first.
+func (l *Limiter) Wait(ctx gocontext.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mtx.Lock()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.opts.Burst), last: l.now()}
+		l.buckets[key] = b
+	}
+	delay := b.reserve(l.now(), l.opts.Rate, l.opts.Burst)
+	l.mtx.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	// Fail fast if the token cannot be granted before the context deadline
+	if deadline, ok := ctx.Deadline(); ok && l.now().Add(delay).After(deadline) {
+		l.cancel(b)
+		return gocontext.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(b)
+		return ctx.Err()
+	}
+}
+
+// cancel gives back a previously reserved token.
+func (l *Limiter) cancel(b *bucket) {
+	l.mtx.Lock()
+	b.tokens++
+	l.mtx.Unlock()
+}
+
+// bucket stores the state of a token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token from the bucket, returning
+// the amount of time to wait until it is available.
+func (b *bucket) reserve(now time.Time, rate float64, burst int) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+
+	elapsed := now.Sub(b.last)
+	if elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestRateLimitPlugin(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	PerHost(10, 1).Exec("before dial", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+	st.Expect(t, ctx.Error, nil)
+}
+
+func TestRateLimitBlocks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(PerHost(20, 1))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		res, err := cli.Request().URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 200)
+	}
+	st.Expect(t, time.Since(start) >= 90*time.Millisecond, true)
+}
+
+func TestRateLimitBuild(t *testing.T) {
+	cli := gentleman.New()
+	cli.Use(PerHost(1, 1))
+
+	// Requests built with no network dialing take no tokens
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := cli.Request().URL("http://localhost:8080").Build()
+		st.Expect(t, err, nil)
+	}
+	st.Expect(t, time.Since(start) < 500*time.Millisecond, true)
+}
+
+func TestRateLimitCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(PerHost(0.1, 1))
+
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+
+	cancelCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = cli.Request().URL(ts.URL).UseRequest(func(ctx *context.Context, h context.Handler) {
+		h.Next(ctx.SetCancelContext(cancelCtx))
+	}).Send()
+	st.Expect(t, errors.Is(err, gocontext.Canceled), true)
+	st.Expect(t, time.Since(start) < time.Second, true)
+}
+
+func TestLimiterPerKey(t *testing.T) {
+	limiter := NewLimiter(Options{Rate: 1, Burst: 1})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	ctx := gocontext.Background()
+	st.Expect(t, limiter.Wait(ctx, "foo"), nil)
+	st.Expect(t, limiter.Wait(ctx, "bar"), nil)
+
+	// Bucket is empty and the token cannot be granted before the deadline
+	deadlineCtx, cancel := gocontext.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	st.Expect(t, limiter.Wait(deadlineCtx, "foo"), gocontext.DeadlineExceeded)
+
+	// Tokens are refilled over time
+	now = now.Add(time.Second)
+	st.Expect(t, limiter.Wait(ctx, "foo"), nil)
+}
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Now()
+	b := &bucket{tokens: 2, last: now}
+	st.Expect(t, b.reserve(now, 10, 2), time.Duration(0))
+	st.Expect(t, b.reserve(now, 10, 2), time.Duration(0))
+	st.Expect(t, b.reserve(now, 10, 2), 100*time.Millisecond)
+	st.Expect(t, b.reserve(now.Add(time.Hour), 10, 2), time.Duration(0))
+	st.Expect(t, b.tokens, float64(1))
+	st.Expect(t, b.reserve(now, 0, 2), time.Duration(0))
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
@@ -0,0 +1,177 @@
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
+)
+
+// Resolver resolves host names into IP addresses.
+// net.Resolver implements this interface.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Options stores the host resolution options.
+type Options struct {
+	// Hosts maps "host:port" patterns to the IP addresses to dial, tried in order.
+	// The host can be a "*.example.com" wildcard matching any subdomain, or "*" matching any host.
+	// The port can be "*" to match any port.
+	Hosts map[string][]string
+
+	// Resolver optionally resolves the hosts with no matching pattern.
+	// Defaults to the transport dialer resolution.
+	Resolver Resolver
+}
+
+// Hosts resolves the given "host:port" patterns into the given IP address,
+// as curl --resolve does. See Options.Hosts for the supported patterns.
+func Hosts(hosts map[string]string) p.Plugin {
+	opts := Options{Hosts: make(map[string][]string, len(hosts))}
+	for host, addr := range hosts {
+		opts.Hosts[host] = []string{addr}
+	}
+	return New(opts)
+}
+
+// New creates a new plugin resolving the dialed addresses based on the given options.
+// The original host is still used for the Host header and TLS server name verification.
+// The transport is cloned on write, leaving the shared one untouched.
+//
+// Plugin instances with the same hosts share the derived transport, but a custom
+// Resolver makes every instance derive its own: register it once at client level.
+func New(opts Options) p.Plugin {
+	hosts := make(map[string][]string, len(opts.Hosts))
+	entries := make([]string, 0, len(opts.Hosts))
+	for pattern, addrs := range opts.Hosts {
+		pattern = strings.ToLower(pattern)
+		hosts[pattern] = addrs
+		entries = append(entries, pattern+"="+strings.Join(addrs, ","))
+	}
+	sort.Strings(entries)
+
+	k := key{hosts: strings.Join(entries, "\n")}
+	if opts.Resolver != nil {
+		k.id = new(int)
+	}
+
+	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
+		// Assert http.Transport to work with the instance
+		transport, ok := ctx.Client.Transport.(*http.Transport)
+		if !ok {
+			// If using a custom transport, just ignore it
+			h.Next(ctx)
+			return
+		}
+
+		// Override the http.Client transport
+		ctx.Client.Transport = utils.Transports(ctx).Derive(transport, k, func(transport *http.Transport) {
+			r := &resolver{hosts: hosts, resolver: opts.Resolver, dial: transport.DialContext}
+			if r.dial == nil {
+				r.dial = (&net.Dialer{}).DialContext
+			}
+			transport.DialContext = r.DialContext
+		})
+
+		h.Next(ctx)
+	})
+}
+
+// key identifies the transports derived by the plugin,
+// either by its hosts or by plugin instance if using a custom resolver.
+type key struct {
+	hosts string
+	id    *int
+}
+
+// resolver dials the resolved addresses via the underlying dialer.
+type resolver struct {
+	hosts    map[string][]string
+	resolver Resolver
+	dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// DialContext dials the given address, trying the resolved IP addresses in order.
+func (r *resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := r.resolve(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return r.dial(ctx, network, addr)
+	}
+
+	// Return the first error, as net.Dialer does
+	var firstErr error
+	for _, ip := range addrs {
+		conn, err := r.dial(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// resolve returns the IP addresses to dial for the given host and port.
+// No addresses are returned if the host must be dialed as it is.
+func (r *resolver) resolve(ctx context.Context, host, port string) ([]string, error) {
+	if addrs, ok := r.match(strings.ToLower(host), port); ok {
+		return addrs, nil
+	}
+	if r.resolver == nil || net.ParseIP(host) != nil {
+		return nil, nil
+	}
+
+	addrs, err := r.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("resolve: no addresses found for host %s", host)
+	}
+	return addrs, nil
+}
+
+// match returns the addresses of the most specific pattern matching the given host and port:
+// the exact host, then the closest "*.domain" wildcard and finally "*", preferring the exact port.
+func (r *resolver) match(host, port string) ([]string, bool) {
+	for _, name := range candidates(host) {
+		if addrs, ok := r.hosts[net.JoinHostPort(name, port)]; ok {
+			return addrs, true
+		}
+		if addrs, ok := r.hosts[net.JoinHostPort(name, "*")]; ok {
+			return addrs, true
+		}
+	}
+	return nil, false
+}
+
+// candidates returns the host patterns which could match the given host, from most to least specific.
+func candidates(host string) []string {
+	names := []string{host}
+	if net.ParseIP(host) == nil {
+		for domain := host; strings.Contains(domain, "."); {
+			domain = domain[strings.Index(domain, ".")+1:]
+			names = append(names, "*."+domain)
+		}
+	}
+	return append(names, "*")
+}
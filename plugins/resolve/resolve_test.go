@@ -0,0 +1,152 @@
+package resolve
+
+import (
+	gocontext "context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+	gtls "gopkg.in/h2non/gentleman.v2/plugins/tls"
+)
+
+func TestHosts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	cli := gentleman.New()
+	cli.Use(Hosts(map[string]string{"api.example.com:" + port: "127.0.0.1"}))
+
+	res, err := cli.Request().URL("http://api.example.com:" + port).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "api.example.com:"+port)
+}
+
+func TestHostsTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.ServerName))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	// The test certificate is valid for example.com, which is used for SNI and verification
+	cli := gentleman.New()
+	cli.Use(gtls.Config(&tls.Config{RootCAs: pool}))
+	cli.Use(Hosts(map[string]string{"*.example.com:*": "127.0.0.1"}))
+
+	res, err := cli.Request().URL("https://www.example.com:" + port).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "www.example.com")
+}
+
+func TestResolverFallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	resolver := &staticResolver{addrs: map[string][]string{"backend": {"127.0.0.1"}}}
+	cli := gentleman.New()
+	cli.Use(New(Options{
+		Hosts:    map[string][]string{"blue:*": {"127.0.0.2", "127.0.0.1"}},
+		Resolver: resolver,
+	}))
+
+	res, err := cli.Request().URL("http://backend:" + port).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "backend:"+port)
+	st.Expect(t, resolver.lookups, []string{"backend"})
+
+	// The addresses are tried in order
+	res, err = cli.Request().URL("http://blue:" + port).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "blue:"+port)
+	st.Expect(t, resolver.lookups, []string{"backend"})
+
+	_, err = cli.Request().URL("http://unknown:" + port).Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, resolver.lookups, []string{"backend", "unknown"})
+}
+
+func TestMatch(t *testing.T) {
+	r := &resolver{hosts: map[string][]string{
+		"api.example.com:443": {"10.0.0.1"},
+		"api.example.com:*":   {"10.0.0.2"},
+		"*.example.com:443":   {"10.0.0.3"},
+		"*.b.example.com:*":   {"10.0.0.4"},
+		"*:8080":              {"10.0.0.5"},
+		"[::1]:443":           {"10.0.0.6"},
+	}}
+
+	cases := []struct {
+		host, port string
+		addr       string
+	}{
+		{"api.example.com", "443", "10.0.0.1"},
+		{"api.example.com", "80", "10.0.0.2"},
+		{"www.example.com", "443", "10.0.0.3"},
+		{"a.b.example.com", "443", "10.0.0.4"},
+		{"www.example.com", "8080", "10.0.0.5"},
+		{"::1", "443", "10.0.0.6"},
+		{"www.example.com", "80", ""},
+		{"example.com", "443", ""},
+	}
+	for _, test := range cases {
+		addrs, ok := r.match(test.host, test.port)
+		st.Expect(t, ok, test.addr != "")
+		if ok {
+			st.Expect(t, addrs[0], test.addr)
+		}
+	}
+}
+
+func TestTransportIsolation(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	Hosts(map[string]string{"localhost:80": "127.0.0.1"}).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+
+	transport := ctx.Client.Transport.(*http.Transport)
+	st.Expect(t, transport != http.DefaultTransport, true)
+}
+
+type staticResolver struct {
+	addrs   map[string][]string
+	lookups []string
+}
+
+func (r *staticResolver) LookupHost(ctx gocontext.Context, host string) ([]string, error) {
+	r.lookups = append(r.lookups, host)
+	if addrs, ok := r.addrs[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("host not found")
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
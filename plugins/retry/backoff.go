@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff defines the interface that must be implemented
+// by retry backoff strategies.
+type Backoff interface {
+	// Next returns the amount of time to wait before the given retry attempt,
+	// starting from 1, based on the previous waiting time.
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+// BackoffFunc is a function type implementing the Backoff interface.
+type BackoffFunc func(attempt int, prev time.Duration) time.Duration
+
+// Next implements the Backoff interface.
+func (fn BackoffFunc) Next(attempt int, prev time.Duration) time.Duration {
+	return fn(attempt, prev)
+}
+
+// Constant returns a backoff strategy that always waits the same amount of time.
+func Constant(wait time.Duration) Backoff {
+	return BackoffFunc(func(int, time.Duration) time.Duration {
+		return wait
+	})
+}
+
+// Exponential implements an exponential backoff strategy
+// with optional full jitter.
+type Exponential struct {
+	// Min defines the waiting time before the first retry.
+	Min time.Duration
+
+	// Max defines the maximum waiting time between attempts.
+	Max time.Duration
+
+	// Factor defines the multiplying factor applied on each attempt. Defaults to 2.
+	Factor float64
+
+	// Jitter enables a random waiting time between zero and the computed one.
+	Jitter bool
+}
+
+// Next implements the Backoff interface.
+func (e *Exponential) Next(attempt int, _ time.Duration) time.Duration {
+	factor := e.Factor
+	if factor <= 0 {
+		factor = 2
+	}
+
+	wait := float64(e.Min) * math.Pow(factor, float64(attempt-1))
+	if e.Max > 0 && wait > float64(e.Max) {
+		wait = float64(e.Max)
+	}
+	if e.Jitter && wait > 0 {
+		wait = random(wait)
+	}
+
+	return time.Duration(wait)
+}
+
+// DecorrelatedJitter implements the decorrelated jitter backoff strategy,
+// where each waiting time is randomly picked between Base and three times
+// the previous one, capped by Cap.
+// See: https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type DecorrelatedJitter struct {
+	// Base defines the minimum waiting time between attempts.
+	Base time.Duration
+
+	// Cap defines the maximum waiting time between attempts.
+	Cap time.Duration
+}
+
+// Next implements the Backoff interface.
+func (d *DecorrelatedJitter) Next(_ int, prev time.Duration) time.Duration {
+	if prev < d.Base {
+		prev = d.Base
+	}
+
+	upper := float64(prev) * 3
+	wait := float64(d.Base) + random(upper-float64(d.Base))
+	if d.Cap > 0 && wait > float64(d.Cap) {
+		wait = float64(d.Cap)
+	}
+
+	return time.Duration(wait)
+}
+
+var (
+	// mtx protects the shared random source
+	mtx sync.Mutex
+
+	source = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// random returns a pseudo-random number in [0, n).
+func random(n float64) float64 {
+	mtx.Lock()
+	defer mtx.Unlock()
+	return source.Float64() * n
+}
@@ -0,0 +1,42 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+)
+
+func TestBackoffConstant(t *testing.T) {
+	backoff := Constant(time.Second)
+	st.Expect(t, backoff.Next(1, 0), time.Second)
+	st.Expect(t, backoff.Next(5, time.Second), time.Second)
+}
+
+func TestBackoffExponential(t *testing.T) {
+	backoff := &Exponential{Min: 100 * time.Millisecond, Max: time.Second}
+	st.Expect(t, backoff.Next(1, 0), 100*time.Millisecond)
+	st.Expect(t, backoff.Next(2, 0), 200*time.Millisecond)
+	st.Expect(t, backoff.Next(3, 0), 400*time.Millisecond)
+	st.Expect(t, backoff.Next(10, 0), time.Second)
+}
+
+func TestBackoffExponentialJitter(t *testing.T) {
+	backoff := &Exponential{Min: 100 * time.Millisecond, Max: time.Second, Jitter: true}
+	for i := 1; i < 10; i++ {
+		wait := backoff.Next(i, 0)
+		st.Expect(t, wait >= 0 && wait <= time.Second, true)
+	}
+}
+
+func TestBackoffDecorrelatedJitter(t *testing.T) {
+	backoff := &DecorrelatedJitter{Base: 100 * time.Millisecond, Cap: time.Second}
+	var wait time.Duration
+	for i := 1; i < 20; i++ {
+		next := backoff.Next(i, wait)
+		st.Expect(t, next >= backoff.Base, true)
+		st.Expect(t, next <= backoff.Cap, true)
+		st.Expect(t, next <= 3*wait || wait < backoff.Base, true)
+		wait = next
+	}
+}
@@ -0,0 +1,286 @@
+package retry
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+var (
+	// MaxRetries defines the default maximum number of retry attempts
+	// performed after the first failed one.
+	MaxRetries = 3
+
+	// StatusCodes defines the default list of server response status codes
+	// that will be considered as a failed attempt.
+	StatusCodes = []int{408, 429, 500, 502, 503, 504}
+
+	// Methods defines the default list of idempotent HTTP methods
+	// whose requests can be safely retried.
+	Methods = []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}
+
+	// DefaultBackoff defines the default backoff strategy used between attempts.
+	DefaultBackoff Backoff = &Exponential{Min: 100 * time.Millisecond, Max: 10 * time.Second, Factor: 2, Jitter: true}
+)
+
+// ErrorClass reports whether the given error belongs to a class of errors
+// that should be retried.
+type ErrorClass func(error) bool
+
+// NetworkError matches any network related error, such as DNS resolution
+// failures, refused connections or abruptly closed sockets.
+var NetworkError ErrorClass = func(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// TimeoutError matches network errors caused by a timeout.
+var TimeoutError ErrorClass = func(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// AnyError matches any error.
+var AnyError ErrorClass = func(err error) bool {
+	return err != nil
+}
+
+// Evaluator is a custom retry policy function that decides if the given attempt
+// must be retried. Either the response or the error can be nil.
+type Evaluator func(req *http.Request, res *http.Response, err error) bool
+
+// Options stores the retry policy options.
+type Options struct {
+	// Retries defines the maximum number of retry attempts after the first one.
+	// Defaults to MaxRetries. Use a negative value to disable retries.
+	Retries int
+
+	// Backoff defines the backoff strategy used to wait between attempts.
+	// Defaults to DefaultBackoff.
+	Backoff Backoff
+
+	// StatusCodes defines the response status codes that will be retried.
+	// Defaults to StatusCodes.
+	StatusCodes []int
+
+	// Errors defines the classes of transport errors that will be retried.
+	// Defaults to NetworkError.
+	Errors []ErrorClass
+
+	// Methods defines the HTTP methods whose requests will be retried.
+	// Defaults to Methods. Non-idempotent methods, such as POST or PATCH,
+	// must be explicitly listed, since retrying them may duplicate side effects.
+	Methods []string
+
+	// Evaluator optionally overrides the built-in method, status and error matching policy.
+	Evaluator Evaluator
+
+	// RetryAfter enables the throttling-aware mode, where the Retry-After header
+	// of 429 and 503 responses takes precedence over the backoff strategy.
+	RetryAfter bool
+
+	// MaxRetryAfter caps the waiting time indicated by the Retry-After header.
+	// Defaults to MaxRetryAfter.
+	MaxRetryAfter time.Duration
+}
+
+// New creates a new retry plugin based on the given options.
+// The plugin wraps the http.Client transport in the "before dial" phase,
+// so every network attempt of the outgoing request is retried transparently.
+func New(opts Options) p.Plugin {
+	return p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		Intercept(ctx, opts)
+		h.Next(ctx)
+	})
+}
+
+// Default creates a new retry plugin with the default options.
+func Default() p.Plugin {
+	return New(Options{})
+}
+
+// Intercept wraps the given context http.Client transport with a retry capable transport.
+func Intercept(ctx *c.Context, opts Options) {
+	ctx.Client.Transport = NewTransport(ctx.Client.Transport, opts)
+}
+
+// Transport implements a retry capable http.RoundTripper.
+type Transport struct {
+	opts      Options
+	transport http.RoundTripper
+}
+
+// NewTransport creates a new retry capable transport wrapping the given one.
+// If transport is nil, http.DefaultTransport will be used.
+func NewTransport(transport http.RoundTripper, opts Options) *Transport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if opts.Retries == 0 {
+		opts.Retries = MaxRetries
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.StatusCodes == nil {
+		opts.StatusCodes = StatusCodes
+	}
+	if opts.Errors == nil {
+		opts.Errors = []ErrorClass{NetworkError}
+	}
+	if opts.Methods == nil {
+		opts.Methods = Methods
+	}
+	if opts.MaxRetryAfter == 0 {
+		opts.MaxRetryAfter = MaxRetryAfter
+	}
+	return &Transport{opts: opts, transport: transport}
+}
+
+// RoundTrip performs the HTTP transaction, retrying it if required by the policy.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests which are never retried are sent as is, with no body buffering
+	if !t.enabled(req) {
+		return t.transport.RoundTrip(req)
+	}
+
+	getBody, err := rewind(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var wait time.Duration
+	for attempt := 0; ; attempt++ {
+		outreq := req
+		if getBody != nil {
+			if outreq, err = withBody(req, getBody); err != nil {
+				return nil, err
+			}
+		}
+
+		res, err := t.transport.RoundTrip(outreq)
+		if attempt >= t.opts.Retries || !t.retriable(req, res, err) {
+			return res, err
+		}
+
+		wait = t.opts.Backoff.Next(attempt+1, wait)
+		if t.opts.RetryAfter {
+			wait = t.throttle(res, wait)
+		}
+		drain(res)
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// enabled returns true if the given request may be retried at all.
+func (t *Transport) enabled(req *http.Request) bool {
+	if t.opts.Retries < 0 {
+		return false
+	}
+	if t.opts.Evaluator != nil {
+		return true
+	}
+	for _, method := range t.opts.Methods {
+		if req.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+// retriable returns true if the given attempt should be retried.
+func (t *Transport) retriable(req *http.Request, res *http.Response, err error) bool {
+	// Never retry if the request was explicitly canceled
+	if req.Context().Err() != nil {
+		return false
+	}
+	if t.opts.Evaluator != nil {
+		return t.opts.Evaluator(req, res, err)
+	}
+
+	if err != nil {
+		for _, class := range t.opts.Errors {
+			if class(err) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, code := range t.opts.StatusCodes {
+		if res.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// rewind returns a function to re-create the request body before each attempt.
+// If the request body cannot be re-created via http.Request.GetBody,
+// it will be entirely buffered in memory.
+func rewind(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+
+	buf, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}, nil
+}
+
+// withBody returns a shallow copy of the given request with a fresh body.
+func withBody(req *http.Request, getBody func() (io.ReadCloser, error)) (*http.Request, error) {
+	body, err := getBody()
+	if err != nil {
+		return nil, err
+	}
+	outreq := new(http.Request)
+	*outreq = *req
+	outreq.Body = body
+	outreq.GetBody = getBody
+	return outreq, nil
+}
+
+// drain consumes and closes the response body, if present,
+// so the underlying connection can be reused.
+func drain(res *http.Response) {
+	if res == nil || res.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+	res.Body.Close()
+}
+
+// sleep waits for the given amount of time, returning early
+// with the context error if the request is canceled.
+func sleep(ctx gocontext.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,252 @@
+package retry
+
+import (
+	gocontext "context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestRetryPlugin(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	New(Options{}).Exec("before dial", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+	_, ok := ctx.Client.Transport.(*Transport)
+	st.Expect(t, ok, true)
+}
+
+func TestRetryServerError(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Backoff: Constant(time.Millisecond)}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "hello")
+	st.Expect(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Retries: 2, Backoff: Constant(time.Millisecond)}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 500)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestRetryNotRetriableStatus(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(400)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Backoff: Constant(time.Millisecond)}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 400)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestRetryRewindsBody(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(502)
+			return
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Methods: []string{"POST"}, Backoff: Constant(time.Millisecond)}))
+
+	// JSON bodies are re-created via http.Request.GetBody
+	res, err := cli.Post().URL(ts.URL).JSON(map[string]string{"foo": "bar"}).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "{\"foo\":\"bar\"}\n")
+
+	// Arbitrary streams are buffered
+	atomic.StoreInt32(&calls, 0)
+	reader := ioutil.NopCloser(strings.NewReader("hello world"))
+	res, err = cli.Post().URL(ts.URL).Body(reader).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, res.String(), "hello world")
+}
+
+func TestRetryMethods(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Retries: 2, Backoff: Constant(time.Millisecond)}))
+
+	// Non-idempotent methods are not retried by default
+	for _, method := range []string{"POST", "PATCH"} {
+		atomic.StoreInt32(&calls, 0)
+		res, err := cli.Request().Method(method).URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 503)
+		st.Expect(t, atomic.LoadInt32(&calls), int32(1))
+	}
+
+	for _, method := range Methods {
+		atomic.StoreInt32(&calls, 0)
+		res, err := cli.Request().Method(method).URL(ts.URL).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 503)
+		st.Expect(t, atomic.LoadInt32(&calls), int32(3))
+	}
+
+	// Other methods can be explicitly enabled
+	cli = gentleman.New()
+	cli.Use(New(Options{Retries: 2, Methods: []string{"PATCH"}, Backoff: Constant(time.Millisecond)}))
+	atomic.StoreInt32(&calls, 0)
+	res, err := cli.Patch().URL(ts.URL).BodyString("foo").Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 503)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestRetryDisabledBody(t *testing.T) {
+	var sent *http.Request
+	transport := roundTripper(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: 503, Body: http.NoBody}, nil
+	})
+
+	// Bodies of requests which are never retried are not buffered
+	for _, opts := range []Options{{Retries: -1}, {}} {
+		body := ioutil.NopCloser(strings.NewReader("foo"))
+		req, _ := http.NewRequest("POST", "http://localhost", body)
+		res, err := NewTransport(transport, opts).RoundTrip(req)
+		st.Expect(t, err, nil)
+		st.Expect(t, res.StatusCode, 503)
+		st.Expect(t, sent.Body, body)
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	var calls int32
+	evaluator := func(req *http.Request, res *http.Response, err error) bool {
+		atomic.AddInt32(&calls, 1)
+		return NetworkError(err)
+	}
+
+	cli := gentleman.New()
+	cli.URL("http://127.0.0.1:9123")
+	cli.Use(New(Options{Retries: 2, Evaluator: evaluator, Backoff: Constant(time.Millisecond)}))
+
+	_, err := cli.Request().Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestRetryEvaluator(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			w.WriteHeader(404)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	evaluator := func(req *http.Request, res *http.Response, err error) bool {
+		return err == nil && res.StatusCode == 404
+	}
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Evaluator: evaluator, Backoff: Constant(time.Millisecond)}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestRetryCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	cancelCtx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	cli := gentleman.New()
+	cli.UseContext(cancelCtx)
+	cli.Use(New(Options{Backoff: Constant(time.Minute)}))
+
+	start := time.Now()
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, errors.Is(err, gocontext.DeadlineExceeded), true)
+	st.Expect(t, time.Since(start) < time.Second, true)
+}
+
+func TestErrorClasses(t *testing.T) {
+	st.Expect(t, NetworkError(errors.New("foo")), false)
+	st.Expect(t, AnyError(errors.New("foo")), true)
+	st.Expect(t, TimeoutError(errors.New("foo")), false)
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
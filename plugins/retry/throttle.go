@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+// MaxRetryAfter defines the default maximum amount of time
+// to wait when honoring a Retry-After response header.
+var MaxRetryAfter = 60 * time.Second
+
+// Throttle creates a new retry plugin that honors the Retry-After header
+// of 429 (Too Many Requests) and 503 (Service Unavailable) responses,
+// waiting up to the given maximum amount of time before each retry.
+// The wait is aborted as soon as the request cancel context is done.
+func Throttle(max time.Duration) p.Plugin {
+	return New(Options{
+		StatusCodes:   []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		RetryAfter:    true,
+		MaxRetryAfter: max,
+	})
+}
+
+// ParseRetryAfter parses the Retry-After header of the given response,
+// supporting both delta-seconds and HTTP-date formats, and returns the
+// amount of time to wait relative to now.
+func ParseRetryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	wait := date.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// throttle returns the amount of time to wait based on the Retry-After
+// header of throttling responses, falling back to the given backoff wait.
+func (t *Transport) throttle(res *http.Response, wait time.Duration) time.Duration {
+	if res == nil {
+		return wait
+	}
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return wait
+	}
+
+	if retryAfter, ok := ParseRetryAfter(res, time.Now()); ok {
+		wait = retryAfter
+	}
+	if wait > t.opts.MaxRetryAfter {
+		wait = t.opts.MaxRetryAfter
+	}
+	return wait
+}
@@ -0,0 +1,108 @@
+package retry
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &http.Response{Header: http.Header{}}
+
+	_, ok := ParseRetryAfter(res, now)
+	st.Expect(t, ok, false)
+
+	res.Header.Set("Retry-After", "120")
+	wait, ok := ParseRetryAfter(res, now)
+	st.Expect(t, ok, true)
+	st.Expect(t, wait, 2*time.Minute)
+
+	res.Header.Set("Retry-After", now.Add(30*time.Second).Format(http.TimeFormat))
+	wait, ok = ParseRetryAfter(res, now)
+	st.Expect(t, ok, true)
+	st.Expect(t, wait, 30*time.Second)
+
+	res.Header.Set("Retry-After", now.Add(-time.Minute).Format(http.TimeFormat))
+	wait, ok = ParseRetryAfter(res, now)
+	st.Expect(t, ok, true)
+	st.Expect(t, wait, time.Duration(0))
+
+	res.Header.Set("Retry-After", "invalid")
+	_, ok = ParseRetryAfter(res, now)
+	st.Expect(t, ok, false)
+
+	res.Header.Set("Retry-After", "-1")
+	_, ok = ParseRetryAfter(res, now)
+	st.Expect(t, ok, false)
+}
+
+func TestThrottle(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(429)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(Throttle(time.Second))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestThrottleMaxWait(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(Throttle(10 * time.Millisecond))
+
+	start := time.Now()
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+	st.Expect(t, time.Since(start) < time.Second, true)
+}
+
+func TestThrottleCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(429)
+	}))
+	defer ts.Close()
+
+	cancelCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	cli := gentleman.New()
+	cli.UseContext(cancelCtx)
+	cli.Use(Throttle(time.Minute))
+
+	start := time.Now()
+	_, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, errors.Is(err, gocontext.Canceled), true)
+	st.Expect(t, time.Since(start) < time.Second, true)
+}
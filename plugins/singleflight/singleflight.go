@@ -0,0 +1,176 @@
+package singleflight
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
+)
+
+// Methods defines the default idempotent HTTP methods eligible for deduplication.
+var Methods = []string{"GET", "HEAD"}
+
+// Credentials defines the request header fields always considered when present,
+// so responses are never shared across callers with different credentials.
+var Credentials = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Options stores the deduplication options.
+type Options struct {
+	// Methods defines the HTTP methods eligible for deduplication. Defaults to Methods.
+	Methods []string
+
+	// Vary defines the request header fields that must match, in addition
+	// to the method, URL and Credentials, for requests to be considered identical.
+	Vary []string
+}
+
+// New creates a new plugin that coalesces concurrent identical requests
+// into a single network call. Every caller gets its own http.Response
+// with an independent copy of the buffered response body.
+func New(opts Options) p.Plugin {
+	group := NewGroup(opts)
+	return p.NewPhasePlugin("before dial", func(ctx *c.Context, h c.Handler) {
+		ctx.Client.Transport = group.Transport(ctx.Client.Transport)
+		h.Next(ctx)
+	})
+}
+
+// call represents an in-flight or completed network call.
+type call struct {
+	done   chan struct{}
+	leader *http.Request
+	res    *http.Response
+	body   []byte
+	err    error
+}
+
+// Group coalesces concurrent identical requests.
+type Group struct {
+	opts  Options
+	mtx   sync.Mutex
+	calls map[string]*call
+}
+
+// NewGroup creates a new deduplication Group based on the given options.
+func NewGroup(opts Options) *Group {
+	if opts.Methods == nil {
+		opts.Methods = Methods
+	}
+	return &Group{opts: opts, calls: make(map[string]*call)}
+}
+
+// Transport returns a new http.RoundTripper that deduplicates requests via
+// the current Group, using the given transport to perform the network calls.
+func (g *Group) Transport(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Transport{group: g, transport: transport}
+}
+
+// Key returns the deduplication key for the given request,
+// or an empty string if the request is not eligible.
+func (g *Group) Key(req *http.Request) string {
+	eligible := false
+	for _, method := range g.opts.Methods {
+		if req.Method == method {
+			eligible = true
+			break
+		}
+	}
+	if !eligible {
+		return ""
+	}
+
+	seen := make(map[string]bool, len(g.opts.Vary))
+	vary := make([]string, 0, len(g.opts.Vary))
+	for _, name := range g.opts.Vary {
+		name = http.CanonicalHeaderKey(name)
+		seen[name] = true
+		vary = append(vary, name+"="+strings.Join(req.Header[name], ","))
+	}
+	for _, name := range Credentials {
+		name = http.CanonicalHeaderKey(name)
+		if values, ok := req.Header[name]; ok && !seen[name] {
+			vary = append(vary, name+"="+strings.Join(values, ","))
+		}
+	}
+	sort.Strings(vary)
+
+	return req.Method + " " + req.URL.String() + "\n" + strings.Join(vary, "\n")
+}
+
+// Transport implements a deduplicating http.RoundTripper.
+type Transport struct {
+	group     *Group
+	transport http.RoundTripper
+}
+
+// RoundTrip performs the HTTP transaction, sharing the response with
+// other concurrent identical requests.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.group.Key(req)
+	if key == "" {
+		return t.transport.RoundTrip(req)
+	}
+
+	g := t.group
+	g.mtx.Lock()
+	if cl, ok := g.calls[key]; ok {
+		g.mtx.Unlock()
+		return t.wait(req, cl)
+	}
+	cl := &call{done: make(chan struct{}), leader: req}
+	g.calls[key] = cl
+	g.mtx.Unlock()
+
+	cl.res, cl.err = t.transport.RoundTrip(req)
+	if cl.err == nil {
+		cl.body, cl.err = ioutil.ReadAll(cl.res.Body)
+		cl.res.Body.Close()
+	}
+
+	g.mtx.Lock()
+	delete(g.calls, key)
+	g.mtx.Unlock()
+	close(cl.done)
+
+	return share(req, cl)
+}
+
+// wait waits for the in-flight call to finish and returns its shared response.
+func (t *Transport) wait(req *http.Request, cl *call) (*http.Response, error) {
+	select {
+	case <-cl.done:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	// The leader request was canceled by its own context: perform the call instead
+	if cl.err != nil && cl.leader.Context().Err() != nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	return share(req, cl)
+}
+
+// share returns a copy of the call response with its own readable body.
+func share(req *http.Request, cl *call) (*http.Response, error) {
+	if cl.err != nil {
+		return nil, cl.err
+	}
+
+	res := new(http.Response)
+	*res = *cl.res
+	res.Header = cl.res.Header.Clone()
+	res.Trailer = cl.res.Trailer.Clone()
+	res.Request = req
+	utils.WriteBodyBytes(res, cl.body)
+
+	return res, nil
+}
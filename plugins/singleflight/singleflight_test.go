@@ -0,0 +1,180 @@
+package singleflight
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestSingleflightPlugin(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	New(Options{}).Exec("before dial", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+	_, ok := ctx.Client.Transport.(*Transport)
+	st.Expect(t, ok, true)
+}
+
+func TestSingleflightCoalesce(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Server", "gentleman")
+		w.Write([]byte("hello world"))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	var wg sync.WaitGroup
+	responses := make([]*gentleman.Response, 5)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := cli.Request().URL(ts.URL).Send()
+			st.Expect(t, err, nil)
+			responses[i] = res
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	st.Expect(t, atomic.LoadInt32(&calls), int32(1))
+	for _, res := range responses {
+		st.Expect(t, res.StatusCode, 200)
+		st.Expect(t, res.Header.Get("Server"), "gentleman")
+		st.Expect(t, res.String(), "hello world")
+	}
+
+	// Response headers are not shared across callers
+	responses[0].Header.Set("Server", "foo")
+	st.Expect(t, responses[1].Header.Get("Server"), "gentleman")
+}
+
+func TestSingleflightVary(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(r.Header.Get("Accept")))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{Vary: []string{"accept"}}))
+
+	var wg sync.WaitGroup
+	for _, accept := range []string{"text/plain", "application/json", "text/plain"} {
+		wg.Add(1)
+		go func(accept string) {
+			defer wg.Done()
+			res, err := cli.Request().URL(ts.URL).SetHeader("Accept", accept).Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.String(), accept)
+		}(accept)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestSingleflightCredentials(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	// Requests with different credentials are never coalesced
+	var wg sync.WaitGroup
+	for _, token := range []string{"Bearer alice", "Bearer bob"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			res, err := cli.Request().URL(ts.URL).SetHeader("Authorization", token).Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.String(), token)
+		}(token)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	st.Expect(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestSingleflightNonIdempotent(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer ts.Close()
+
+	cli := gentleman.New()
+	cli.Use(New(Options{}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cli.Post().URL(ts.URL).Send()
+			st.Expect(t, err, nil)
+		}()
+	}
+	wg.Wait()
+
+	st.Expect(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestGroupKey(t *testing.T) {
+	group := NewGroup(Options{Vary: []string{"Authorization"}})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/bar?baz=1", nil)
+	req.Header.Set("Authorization", "Bearer foo")
+	st.Expect(t, group.Key(req), "GET http://foo.com/bar?baz=1\nAuthorization=Bearer foo")
+
+	group = NewGroup(Options{})
+	req.Header.Set("Cookie", "session=foo")
+	st.Expect(t, group.Key(req), "GET http://foo.com/bar?baz=1\nAuthorization=Bearer foo\nCookie=session=foo")
+
+	req, _ = http.NewRequest("DELETE", "http://foo.com", nil)
+	st.Expect(t, group.Key(req), "")
+}
+
+type handler struct {
+	fn     context.Handler
+	called bool
+}
+
+func newHandler() *handler {
+	h := &handler{}
+	h.fn = context.NewHandler(func(c *context.Context) {
+		h.called = true
+	})
+	return h
+}
@@ -1,9 +1,11 @@
 package timeout
 
 import (
+	"context"
 	g "gopkg.in/h2non/gentleman.v2"
 	c "gopkg.in/h2non/gentleman.v2/context"
 	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
 	"net"
 	"net/http"
 	"time"
@@ -43,7 +45,11 @@ func Dial(timeout, keepAlive time.Duration) p.Plugin {
 	return All(Timeouts{Dial: timeout, KeepAlive: keepAlive})
 }
 
-// All defines all the timeout types for the outgoing request
+// All defines all the timeout types for the outgoing request.
+// The transport is cloned on write, leaving the shared one untouched.
+// The transport dialer, if any, is preserved and bounded by the dial timeout,
+// so custom dialers such as Unix sockets keep working. Custom dialers with their own
+// timeout are bounded by both, while the default dialer only uses the dial timeout.
 func All(timeouts Timeouts) p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		defineTimeouts(timeouts, ctx)
@@ -67,8 +73,6 @@ func defineTimeouts(timeouts Timeouts, ctx *c.Context) {
 	if timeouts.TLS == 0 {
 		timeouts.TLS = g.TLSHandshakeTimeout
 	}
-	transport.TLSHandshakeTimeout = timeouts.TLS
-
 	if timeouts.Dial == 0 {
 		timeouts.Dial = g.DialTimeout
 	}
@@ -76,11 +80,39 @@ func defineTimeouts(timeouts Timeouts, ctx *c.Context) {
 		timeouts.KeepAlive = g.DialKeepAlive
 	}
 
-	transport.Dial = (&net.Dialer{
-		Timeout:   timeouts.Dial,
-		KeepAlive: timeouts.KeepAlive,
-	}).Dial
-
 	// Finally expose the transport to be used
-	ctx.Client.Transport = transport
+	// Transports only depend on the network timeouts
+	k := timeouts
+	k.Request = 0
+	ctx.Client.Transport = utils.Transports(ctx).Derive(transport, k, func(transport *http.Transport) {
+		transport.TLSHandshakeTimeout = timeouts.TLS
+		transport.DialContext = dialContext(transport.DialContext, timeouts)
+	})
+}
+
+// dialContext wraps the given dialer with the given timeouts,
+// or returns a new TCP dialer if there is no dialer.
+func dialContext(dial func(context.Context, string, string) (net.Conn, error), timeouts Timeouts) func(context.Context, string, string) (net.Conn, error) {
+	if dial == nil {
+		return (&net.Dialer{
+			Timeout:   timeouts.Dial,
+			KeepAlive: timeouts.KeepAlive,
+		}).DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// Default dialers use the dial timeout instead of their own one
+		ctx, cancel := context.WithTimeout(g.WithDialTimeout(ctx, timeouts.Dial), timeouts.Dial)
+		defer cancel()
+
+		conn, err := dial(ctx, network, addr)
+		if tcp, ok := conn.(*net.TCPConn); ok && err == nil {
+			// A negative keep alive period disables keep alive probes, as in net.Dialer
+			tcp.SetKeepAlive(timeouts.KeepAlive > 0)
+			if timeouts.KeepAlive > 0 {
+				tcp.SetKeepAlivePeriod(timeouts.KeepAlive)
+			}
+		}
+		return conn, err
+	}
 }
@@ -1,10 +1,17 @@
 package timeout
 
 import (
+	gocontext "context"
+	"errors"
 	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
 	"gopkg.in/h2non/gentleman.v2/context"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestTimeout(t *testing.T) {
@@ -36,6 +43,10 @@ func TestTimeoutAll(t *testing.T) {
 	transport := ctx.Client.Transport.(*http.Transport)
 	st.Expect(t, int(ctx.Client.Timeout), 1000)
 	st.Expect(t, int(transport.TLSHandshakeTimeout), 1000)
+
+	// The shared transport is cloned on write
+	st.Expect(t, transport != http.DefaultTransport, true)
+	st.Reject(t, int(http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout), 1000)
 }
 
 type handler struct {
@@ -50,3 +61,69 @@ func newHandler() *handler {
 	})
 	return h
 }
+
+func TestTimeoutCustomDialer(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+
+	var deadline bool
+	transport := &http.Transport{
+		DialContext: func(ctx gocontext.Context, network, addr string) (net.Conn, error) {
+			_, deadline = ctx.Deadline()
+			return nil, errors.New("dial error")
+		},
+	}
+	ctx.Client.Transport = transport
+
+	Dial(1000, 1000).Exec("request", ctx, fn.fn)
+	st.Expect(t, fn.called, true)
+
+	// The custom dialer is preserved and bounded by the dial timeout
+	dial := ctx.Client.Transport.(*http.Transport).DialContext
+	_, err := dial(gocontext.Background(), "tcp", "localhost:80")
+	st.Expect(t, err.Error(), "dial error")
+	st.Expect(t, deadline, true)
+}
+
+func TestTimeoutRequestReuse(t *testing.T) {
+	var conns int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	// Plugins created per request reuse the same transport and connections
+	cli := gentleman.New().URL(ts.URL)
+	for i := 0; i < 50; i++ {
+		res, err := cli.Request().Use(Dial(time.Second, time.Second)).Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "ok")
+	}
+	st.Expect(t, atomic.LoadInt32(&conns), int32(1))
+}
+
+func TestTimeoutDialAboveDefault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// Make the default dialer timeout expire before connecting
+	timeout := gentleman.DefaultDialer.Timeout
+	gentleman.DefaultDialer.Timeout = time.Nanosecond
+	defer func() { gentleman.DefaultDialer.Timeout = timeout }()
+
+	_, err := gentleman.New().URL(ts.URL).Request().Send()
+	st.Reject(t, err, nil)
+
+	// A longer dial timeout replaces the default dialer one
+	res, err := gentleman.New().URL(ts.URL).Request().Use(Dial(time.Minute, time.Second)).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "ok")
+}
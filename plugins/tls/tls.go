@@ -4,10 +4,13 @@ import (
 	"crypto/tls"
 	c "gopkg.in/h2non/gentleman.v2/context"
 	p "gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/utils"
 	"net/http"
 )
 
-// Config defines the request TLS connection config
+// Config defines the request TLS connection config.
+// The transport is cloned on write, leaving the shared one untouched,
+// and reused by the requests using the same config instance.
 func Config(config *tls.Config) p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		// Assert http.Transport to work with the instance
@@ -19,9 +22,15 @@ func Config(config *tls.Config) p.Plugin {
 		}
 
 		// Override the http.Client transport
-		transport.TLSClientConfig = config
-		ctx.Client.Transport = transport
+		ctx.Client.Transport = utils.Transports(ctx).Derive(transport, key{config}, func(transport *http.Transport) {
+			transport.TLSClientConfig = config
+		})
 
 		h.Next(ctx)
 	})
 }
+
+// key identifies the transports derived for a TLS config.
+type key struct {
+	config *tls.Config
+}
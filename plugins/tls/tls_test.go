@@ -17,6 +17,10 @@ func TestAuthBasic(t *testing.T) {
 
 	transport := ctx.Client.Transport.(*http.Transport)
 	st.Expect(t, transport.TLSClientConfig, config)
+
+	// The shared transport is cloned on write
+	st.Expect(t, transport != http.DefaultTransport, true)
+	st.Reject(t, http.DefaultTransport.(*http.Transport).TLSClientConfig, config)
 }
 
 type handler struct {
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	gocontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SpanContext represents the W3C trace context of a span.
+// See: https://www.w3.org/TR/trace-context/
+type SpanContext struct {
+	// TraceID identifies the whole trace.
+	TraceID [16]byte
+
+	// SpanID identifies the span within the trace.
+	SpanID [8]byte
+
+	// Flags stores the trace flags, such as the sampled flag.
+	Flags byte
+
+	// State stores the vendor-specific tracestate header value.
+	State string
+}
+
+// Sampled reports whether the sampled trace flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&0x01 == 0x01
+}
+
+// IsValid reports whether the trace and span IDs are not zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent returns the traceparent header value of the span context.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), sc.Flags)
+}
+
+// Inject sets the traceparent and tracestate header fields.
+func (sc SpanContext) Inject(header http.Header) {
+	header.Set("traceparent", sc.TraceParent())
+	if sc.State != "" {
+		header.Set("tracestate", sc.State)
+	} else {
+		header.Del("tracestate")
+	}
+}
+
+// Extract returns the span context defined by the traceparent and tracestate
+// header fields, reporting whether a valid traceparent was found.
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, ok := ParseTraceParent(header.Get("traceparent"))
+	if !ok {
+		return SpanContext{}, false
+	}
+	sc.State = strings.Join(header["Tracestate"], ",")
+	return sc, true
+}
+
+// ParseTraceParent parses the given traceparent header value.
+func ParseTraceParent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	// Version 00 defines exactly four fields
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if !decode(sc.TraceID[:], parts[1]) || !decode(sc.SpanID[:], parts[2]) {
+		return sc, false
+	}
+	var flags [1]byte
+	if !decode(flags[:], parts[3]) {
+		return sc, false
+	}
+	sc.Flags = flags[0]
+	return sc, sc.IsValid()
+}
+
+func decode(dst []byte, value string) bool {
+	if len(value) != hex.EncodedLen(len(dst)) || strings.ToLower(value) != value {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(value))
+	return err == nil
+}
+
+// contextKey is the key used to store span contexts in a context.Context.
+type contextKey struct{}
+
+// ContextWithSpanContext returns a copy of the given context.Context
+// storing the given span context, which is used as parent of the
+// spans created for requests using the context.
+func ContextWithSpanContext(ctx gocontext.Context, sc SpanContext) gocontext.Context {
+	return gocontext.WithValue(ctx, contextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context stored in the given context.Context, if any.
+func SpanContextFromContext(ctx gocontext.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// newID fills the given slice with random bytes.
+func newID(id []byte) {
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,204 @@
+package tracing
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	c "gopkg.in/h2non/gentleman.v2/context"
+	p "gopkg.in/h2non/gentleman.v2/plugin"
+)
+
+// Tracer defines the interface that must be implemented by tracers,
+// allowing to plug in any tracing backend.
+type Tracer interface {
+	// Start starts a new client span for the given request context. Implementations
+	// are expected to inject their propagation header fields into ctx.Request.
+	Start(ctx *c.Context, name string) Span
+}
+
+// Span defines the interface implemented by spans created by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span.
+	SetAttribute(key string, value interface{})
+
+	// End finishes the span, recording the given error, if any.
+	End(err error)
+}
+
+// W3C is the default Tracer, which propagates the W3C traceparent and tracestate
+// header fields and hands finished spans to the Export function, if any.
+//
+// Spans continue the trace defined by the request traceparent header, if present,
+// or by the span context stored in the request context.Context via ContextWithSpanContext.
+// Otherwise, a new sampled trace is started.
+type W3C struct {
+	// Export is called with every finished span.
+	Export func(*SpanData)
+}
+
+// Start starts a new client span and injects its trace context into the request.
+func (t *W3C) Start(ctx *c.Context, name string) Span {
+	parent, ok := Extract(ctx.Request.Header)
+	if !ok {
+		parent, ok = SpanContextFromContext(ctx)
+	}
+
+	span := &SpanData{Name: name, StartTime: time.Now(), Attributes: map[string]interface{}{}, export: t.Export}
+	if ok {
+		span.Parent = parent
+		span.Context = SpanContext{TraceID: parent.TraceID, Flags: parent.Flags, State: parent.State}
+	} else {
+		newID(span.Context.TraceID[:])
+		span.Context.Flags = 0x01
+	}
+	newID(span.Context.SpanID[:])
+
+	span.Context.Inject(ctx.Request.Header)
+	return span
+}
+
+// SpanData represents a span created by the W3C tracer.
+type SpanData struct {
+	mtx    sync.Mutex
+	export func(*SpanData)
+
+	// Name stores the span name.
+	Name string
+
+	// Context stores the span context.
+	Context SpanContext
+
+	// Parent stores the parent span context, if any.
+	Parent SpanContext
+
+	// StartTime stores the time when the span started.
+	StartTime time.Time
+
+	// EndTime stores the time when the span finished.
+	EndTime time.Time
+
+	// Attributes stores the span attributes.
+	Attributes map[string]interface{}
+
+	// Error stores the error the span finished with, if any.
+	Error error
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *SpanData) SetAttribute(key string, value interface{}) {
+	s.mtx.Lock()
+	s.Attributes[key] = value
+	s.mtx.Unlock()
+}
+
+// End finishes the span, exporting it if required.
+func (s *SpanData) End(err error) {
+	s.mtx.Lock()
+	s.EndTime, s.Error = time.Now(), err
+	s.mtx.Unlock()
+	if s.export != nil {
+		s.export(s)
+	}
+}
+
+// active stores the span of an in-flight request.
+type active struct {
+	once sync.Once
+	span Span
+}
+
+// end finishes the span only once.
+func (a *active) end(err error) {
+	a.once.Do(func() { a.span.End(err) })
+}
+
+// Tracing implements a plugin that creates a client span per request.
+//
+// The span is started in the "before dial" phase, once the request URL is known,
+// and finished once the "response", "error" or "stop" phase finishes.
+type Tracing struct {
+	// Tracing implements the plugin interface.
+	*p.Layer
+
+	tracer Tracer
+}
+
+// New creates a new tracing plugin based on the given Tracer.
+// If no tracer is given, a W3C tracer that propagates the trace context
+// without exporting spans is used.
+func New(tracer Tracer) *Tracing {
+	if tracer == nil {
+		tracer = &W3C{}
+	}
+
+	t := &Tracing{Layer: p.New(), tracer: tracer}
+	t.SetHandlers(p.Handlers{
+		"before dial": t.start,
+		"response":    t.response,
+		"error":       t.error,
+		"stop":        t.stop,
+	})
+	return t
+}
+
+// SpanOf returns the span of the given request context created
+// by the given tracing plugin, if any.
+func (t *Tracing) SpanOf(ctx *c.Context) Span {
+	if a, ok := ctx.Get(t).(*active); ok {
+		return a.span
+	}
+	return nil
+}
+
+func (t *Tracing) start(ctx *c.Context, h c.Handler) {
+	// Requests built with no network dialing, such as by Request.Build, are not traced
+	if ctx.Get("$dryrun") == true {
+		h.Next(ctx)
+		return
+	}
+
+	req := ctx.Request
+	s := t.tracer.Start(ctx, "HTTP "+req.Method)
+	s.SetAttribute("http.request.method", req.Method)
+	s.SetAttribute("url.full", req.URL.String())
+	s.SetAttribute("server.address", req.URL.Hostname())
+	if port := req.URL.Port(); port != "" {
+		s.SetAttribute("server.port", port)
+	}
+
+	ctx.Set(t, &active{span: s})
+	h.Next(ctx)
+}
+
+func (t *Tracing) response(ctx *c.Context, h c.Handler) {
+	if a, ok := ctx.Get(t).(*active); ok {
+		a.span.SetAttribute("http.response.status_code", ctx.Response.StatusCode)
+		defer a.end(nil)
+	}
+	h.Next(ctx)
+}
+
+func (t *Tracing) error(ctx *c.Context, h c.Handler) {
+	a, ok := ctx.Get(t).(*active)
+	if !ok || ctx.Error == nil {
+		h.Next(ctx)
+		return
+	}
+
+	defer func() {
+		// An error recovered by the middleware lets the transaction continue
+		if ctx.Error != nil {
+			a.span.SetAttribute("error.type", fmt.Sprintf("%T", ctx.Error))
+			a.end(ctx.Error)
+		}
+	}()
+	h.Next(ctx)
+}
+
+func (t *Tracing) stop(ctx *c.Context, h c.Handler) {
+	if a, ok := ctx.Get(t).(*active); ok {
+		defer a.end(ctx.Error)
+	}
+	h.Next(ctx)
+}
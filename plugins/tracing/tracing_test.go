@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2"
+)
+
+// recorder collects the spans exported by the W3C tracer.
+type recorder struct {
+	mtx   sync.Mutex
+	spans []*SpanData
+}
+
+func (r *recorder) export(span *SpanData) {
+	r.mtx.Lock()
+	r.spans = append(r.spans, span)
+	r.mtx.Unlock()
+}
+
+func TestTracingNewTrace(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(201)
+	}))
+	defer ts.Close()
+
+	rec := &recorder{}
+	cli := gentleman.New()
+	cli.Use(New(&W3C{Export: rec.export}))
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 201)
+
+	st.Expect(t, len(rec.spans), 1)
+	span := rec.spans[0]
+	st.Expect(t, span.Name, "HTTP GET")
+	st.Expect(t, span.Parent.IsValid(), false)
+	st.Expect(t, span.Context.Sampled(), true)
+	st.Expect(t, traceparent, span.Context.TraceParent())
+	st.Expect(t, span.Attributes["http.request.method"], "GET")
+	st.Expect(t, span.Attributes["url.full"], ts.URL)
+	st.Expect(t, span.Attributes["server.address"], "127.0.0.1")
+	st.Expect(t, span.Attributes["http.response.status_code"], 201)
+	st.Expect(t, span.Error, nil)
+	st.Expect(t, span.EndTime.After(span.StartTime), true)
+}
+
+func TestTracingPropagation(t *testing.T) {
+	var traceparent, tracestate string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		tracestate = r.Header.Get("tracestate")
+	}))
+	defer ts.Close()
+
+	rec := &recorder{}
+	cli := gentleman.New()
+	cli.Use(New(&W3C{Export: rec.export}))
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	_, err := cli.Request().URL(ts.URL).
+		SetHeader("traceparent", parent).
+		SetHeader("tracestate", "foo=bar").
+		Send()
+	st.Expect(t, err, nil)
+
+	span := rec.spans[0]
+	st.Expect(t, span.Parent.TraceParent(), parent)
+	st.Expect(t, span.Context.TraceID, span.Parent.TraceID)
+	st.Reject(t, span.Context.SpanID, span.Parent.SpanID)
+	st.Expect(t, traceparent, span.Context.TraceParent())
+	st.Expect(t, tracestate, "foo=bar")
+
+	// Parent span context from context.Context
+	sc, _ := ParseTraceParent(parent)
+	req := cli.Request().URL(ts.URL)
+	req.Context.SetCancelContext(ContextWithSpanContext(gocontext.Background(), sc))
+	_, err = req.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, rec.spans[1].Context.TraceID, sc.TraceID)
+	st.Expect(t, rec.spans[1].Parent.SpanID, sc.SpanID)
+}
+
+func TestTracingError(t *testing.T) {
+	rec := &recorder{}
+	cli := gentleman.New()
+	cli.Use(New(&W3C{Export: rec.export}))
+
+	_, err := cli.Request().URL("http://127.0.0.1:9123").Send()
+	st.Reject(t, err, nil)
+	st.Expect(t, len(rec.spans), 1)
+	st.Expect(t, rec.spans[0].Error, err)
+	st.Reject(t, rec.spans[0].Attributes["error.type"], nil)
+}
+
+func TestParseTraceParent(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6-00f067aa0ba902b7-01", false},
+		{"foo", false},
+	}
+
+	for _, test := range cases {
+		sc, ok := ParseTraceParent(test.value)
+		st.Expect(t, ok, test.valid)
+		if ok && len(test.value) == 55 {
+			st.Expect(t, sc.TraceParent(), test.value)
+		}
+	}
+}
@@ -9,6 +9,16 @@ import (
 	p "gopkg.in/h2non/gentleman.v2/plugin"
 )
 
+// templateKey stores the URL path template in the context store.
+const templateKey = "$path"
+
+// Template returns the URL path template of the outgoing request, as defined
+// via URL, BaseURL, Path, AddPath or PathPrefix, before path params are replaced.
+// Useful as low-cardinality route identifier (e.g: "/users/:id").
+func Template(ctx *c.Context) string {
+	return ctx.GetString(templateKey)
+}
+
 // URL parses and defines a new URL in the outgoing request
 func URL(uri string) p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
@@ -19,6 +29,7 @@ func URL(uri string) p.Plugin {
 		}
 
 		ctx.Request.URL = u
+		ctx.Set(templateKey, u.Path)
 		h.Next(ctx)
 	})
 }
@@ -37,6 +48,7 @@ func BaseURL(uri string) p.Plugin {
 
 		if u.Path != "" && u.Path != "/" {
 			ctx.Request.URL.Path = normalizePath(u.Path)
+			ctx.Set(templateKey, ctx.Request.URL.Path)
 		}
 
 		h.Next(ctx)
@@ -47,6 +59,7 @@ func BaseURL(uri string) p.Plugin {
 func Path(path string) p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		ctx.Request.URL.Path = normalizePath(path)
+		ctx.Set(templateKey, normalizePath(path))
 		h.Next(ctx)
 	})
 }
@@ -55,6 +68,7 @@ func Path(path string) p.Plugin {
 func AddPath(path string) p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		ctx.Request.URL.Path += normalizePath(path)
+		ctx.Set(templateKey, Template(ctx)+normalizePath(path))
 		h.Next(ctx)
 	})
 }
@@ -63,6 +77,7 @@ func AddPath(path string) p.Plugin {
 func PathPrefix(path string) p.Plugin {
 	return p.NewRequestPlugin(func(ctx *c.Context, h c.Handler) {
 		ctx.Request.URL.Path = normalizePath(path) + ctx.Request.URL.Path
+		ctx.Set(templateKey, normalizePath(path)+Template(ctx))
 		h.Next(ctx)
 	})
 }
@@ -147,6 +147,28 @@ func TestPathParams(t *testing.T) {
 	}
 }
 
+func TestTemplate(t *testing.T) {
+	ctx := context.New()
+	fn := newHandler()
+	st.Expect(t, Template(ctx), "")
+
+	BaseURL("http://foo/api").Exec("request", ctx, fn.fn)
+	st.Expect(t, Template(ctx), "/api")
+	AddPath("/users/:id").Exec("request", ctx, fn.fn)
+	st.Expect(t, Template(ctx), "/api/users/:id")
+	Param("id", "123").Exec("request", ctx, fn.fn)
+	Params(map[string]string{"id": "456"}).Exec("request", ctx, fn.fn)
+	st.Expect(t, ctx.Request.URL.Path, "/api/users/123")
+	st.Expect(t, Template(ctx), "/api/users/:id")
+	PathPrefix("/v1").Exec("request", ctx, fn.fn)
+	st.Expect(t, Template(ctx), "/v1/api/users/:id")
+
+	Path("/repos/:owner").Exec("request", ctx, fn.fn)
+	st.Expect(t, Template(ctx), "/repos/:owner")
+	URL("http://foo/orgs/:org").Exec("request", ctx, fn.fn)
+	st.Expect(t, Template(ctx), "/orgs/:org")
+}
+
 func assert(t *testing.T, fn *handler, ctx *context.Context, test test) {
 	st.Expect(t, fn.called, true)
 	st.Expect(t, ctx.Error, nil)
@@ -1,10 +1,12 @@
 package gentleman
 
 import (
+	gocontext "context"
 	"errors"
 	"io"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/h2non/gentleman.v2/context"
@@ -53,9 +55,12 @@ var (
 // Request HTTP entity for gentleman.
 // Provides middleware capabilities, built-in context
 // and convenient methods to easily setup request params.
+//
+// A Request is a reusable template: every Send() dispatches a fresh copy of it,
+// so it can be sent repeatedly or concurrently.
 type Request struct {
-	// Stores if the request was already dispatched
-	dispatched bool
+	// Stores if the body, which cannot be re-created, was already dispatched
+	sent int32
 
 	// Optional reference to the gentleman.Client instance
 	Client *Client
@@ -268,17 +273,74 @@ func (r *Request) Send() (*Response, error) {
 }
 
 // Do performs the HTTP request and returns the HTTP response.
+//
+// The request is dispatched on a copy of its context, headers and body,
+// so it can be performed again. The dispatched context is exposed via Response.Context.
+// Bodies defined by streams with no GetBody function, such as arbitrary
+// io.Reader bodies, can only be sent once: further calls return an error.
 func (r *Request) Do() (*Response, error) {
-	if r.dispatched {
-		return nil, errors.New("gentleman: Request was already dispatched")
+	req, err := r.fork()
+	if err != nil {
+		return nil, err
 	}
 
-	r.dispatched = true
-	ctx := NewDispatcher(r).Dispatch()
-
+	ctx := NewDispatcher(req).Dispatch()
 	return buildResponse(ctx)
 }
 
+// Build runs the "request" and "before dial" middleware phases on a clone
+// of the current request with no network dialing, returning the final http.Request.
+// The "stop" phase is finally run, so the middleware releases its resources.
+func (r *Request) Build() (*http.Request, error) {
+	ctx, err := r.dryRun("request", "before dial")
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Request, nil
+}
+
+// BuildClient is like Build, but also returns the http.Client
+// configured by the middleware to perform the request.
+func (r *Request) BuildClient() (*http.Client, *http.Request, error) {
+	ctx, err := r.dryRun("request", "before dial")
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx.Client, ctx.Request, nil
+}
+
+// dryRun runs the given middleware phases on a side-effects free copy of the request.
+//
+// The context is flagged via the "$dryrun" store key, so plugins can skip side effects
+// such as waiting for rate limits, and the "stop" phase is finally run to release
+// any resource acquired by the middleware, since the request is never dialed.
+func (r *Request) dryRun(phases ...string) (*context.Context, error) {
+	req, err := r.fork()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context
+	ctx.Set("$dryrun", true)
+	dispatcher := NewDispatcher(req)
+	for _, phase := range phases {
+		ctx, _ = dispatcher.run(phase, ctx)
+		if ctx.Error != nil {
+			return nil, ctx.Error
+		}
+		if ctx.Stopped {
+			break
+		}
+	}
+
+	ctx.Stopped = true
+	ctx = dispatcher.runPhase("stop", ctx)
+	if ctx.Error != nil {
+		return nil, ctx.Error
+	}
+	return ctx, nil
+}
+
 // Use uses a new plugin in the middleware stack.
 func (r *Request) Use(p plugin.Plugin) *Request {
 	r.Middleware.Use(p)
@@ -318,12 +380,52 @@ func (r *Request) Clone() *Request {
 	return req
 }
 
+// fork returns a copy of the request to be dispatched, re-creating the body if possible.
+// Bodies that cannot be re-created can only be dispatched once.
+func (r *Request) fork() (*Request, error) {
+	req := r.Clone()
+	ctx := req.Context
+
+	if ctx.Request.GetBody != nil {
+		body, err := ctx.Request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		ctx.Request.Body = body
+	} else if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+		if !atomic.CompareAndSwapInt32(&r.sent, 0, 1) {
+			return nil, errors.New("gentleman: Request body was already sent and cannot be re-created")
+		}
+	}
+
+	return req, nil
+}
+
+// dialTimeoutKey stores the context key of the dial timeout defined via WithDialTimeout.
+type dialTimeoutKey struct{}
+
+// WithDialTimeout returns a copy of the given dial context overriding the timeout of
+// the dialers used by the transports created via NewDefaultTransport, such as DefaultTransport.
+// Plugins wrapping the transport dialer can use it to define a timeout longer than DialTimeout.
+func WithDialTimeout(ctx gocontext.Context, timeout time.Duration) gocontext.Context {
+	return gocontext.WithValue(ctx, dialTimeoutKey{}, timeout)
+}
+
 // NewDefaultTransport returns a new http.Transport with default values
 // based on the given net.Dialer.
 func NewDefaultTransport(dialer *net.Dialer) *http.Transport {
+	dial := func(ctx gocontext.Context, network, addr string) (net.Conn, error) {
+		if timeout, ok := ctx.Value(dialTimeoutKey{}).(time.Duration); ok {
+			d := *dialer
+			d.Timeout = timeout
+			return d.DialContext(ctx, network, addr)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
 	transport := &http.Transport{
 		Proxy:               http.ProxyFromEnvironment,
-		Dial:                dialer.Dial,
+		DialContext:         dial,
 		TLSHandshakeTimeout: TLSHandshakeTimeout,
 	}
 	return transport
@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -44,7 +45,7 @@ func TestRequest(t *testing.T) {
 	st.Expect(t, res.StatusCode, 200)
 }
 
-func TestRequestAlreadyDispatched(t *testing.T) {
+func TestRequestSendAgain(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, "Hello, world")
 	}))
@@ -66,8 +67,89 @@ func TestRequestAlreadyDispatched(t *testing.T) {
 	st.Reject(t, res.RawRequest.URL, nil)
 	st.Expect(t, res.StatusCode, 200)
 
+	// Requests can be sent again
 	res, err = req.Send()
-	st.Reject(t, err, nil)
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 200)
+}
+
+func TestRequestSendConcurrently(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("n"), r.Header.Get("X-Count"), body)
+	}))
+	defer ts.Close()
+
+	var count int32
+	req := NewRequest().URL(ts.URL).SetQuery("n", "1").BodyString("hello")
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		n := atomic.AddInt32(&count, 1)
+		ctx.Request.Header.Add("X-Count", fmt.Sprint(n))
+		ctx.Set("n", n)
+		h.Next(ctx)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := req.Send()
+			st.Expect(t, err, nil)
+			n, _ := res.Context.Get("n").(int32)
+			st.Expect(t, res.String(), fmt.Sprintf("1 %d hello", n))
+		}()
+	}
+	wg.Wait()
+
+	// The template remains untouched
+	st.Expect(t, atomic.LoadInt32(&count), int32(10))
+	st.Expect(t, req.Context.Request.Header.Get("X-Count"), "")
+	st.Expect(t, req.Context.Get("n"), nil)
+
+	res, err := req.Body(bytes.NewBufferString("buffer")).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, strings.HasSuffix(res.String(), " buffer"), true)
+	res, err = req.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, strings.HasSuffix(res.String(), " buffer"), true)
+}
+
+func TestRequestSendAgainBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if file, _, err := r.FormFile("file"); err == nil {
+			body, _ := ioutil.ReadAll(file)
+			fmt.Fprintf(w, "file=%s", body)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	// Multipart files are re-sent
+	req := NewRequest().URL(ts.URL).File("file", ioutil.NopCloser(strings.NewReader("content")))
+	for i := 0; i < 2; i++ {
+		res, err := req.Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "file=content")
+	}
+
+	// Streams which cannot be re-created are never re-sent as an empty body
+	req = NewRequest().URL(ts.URL).Body(ioutil.NopCloser(strings.NewReader("stream")))
+	res, err := req.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "stream")
+	_, err = req.Send()
+	st.Expect(t, err.Error(), "gentleman: request body stream was already sent")
+
+	req = NewRequest().URL(ts.URL)
+	req.Context.Request.Body = ioutil.NopCloser(strings.NewReader("raw"))
+	res, err = req.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "raw")
+	_, err = req.Send()
+	st.Expect(t, err.Error(), "gentleman: Request body was already sent and cannot be re-created")
 }
 
 func TestMiddlewareErrorInjectionAndInterception(t *testing.T) {
@@ -543,6 +625,103 @@ func TestRequestClone(t *testing.T) {
 	st.Expect(t, len(req2.Middleware.GetStack()), 1)
 }
 
+func TestRequestCloneConcurrently(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.URL.Path, r.Header.Get("X-Id"))
+	}))
+	defer ts.Close()
+
+	cli := New()
+	cli.URL(ts.URL)
+	req := cli.Request().SetHeader("X-Foo", "foo")
+	req.Context.Request.Header.Set("X-Id", "template")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clone := req.Clone()
+			clone.Context.Request.Header.Set("X-Id", fmt.Sprint(i))
+			clone.Context.Set("id", i)
+			clone.Path(fmt.Sprintf("/%d", i))
+
+			res, err := clone.Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.String(), fmt.Sprintf("/%d %d", i, i))
+			st.Expect(t, res.Context.Get("id"), i)
+		}(i)
+	}
+	wg.Wait()
+
+	st.Expect(t, req.Context.Request.Header.Get("X-Id"), "template")
+	st.Expect(t, req.Context.Get("id"), nil)
+	st.Expect(t, len(req.Middleware.GetStack()), 1)
+}
+
+func TestRequestBuild(t *testing.T) {
+	cli := New()
+	cli.URL("http://localhost:8080/api")
+	cli.SetHeader("X-Client", "foo")
+
+	req := cli.Post().AddPath("/users/:id").Param("id", "123").SetQuery("q", "bar").JSON(map[string]int{"a": 1})
+	req.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		ctx.Request.Header.Set("X-Dial", "before")
+		ctx.Client.Timeout = time.Second
+		h.Next(ctx)
+	})
+
+	hr, err := req.Build()
+	st.Expect(t, err, nil)
+	st.Expect(t, hr.Method, "POST")
+	st.Expect(t, hr.URL.String(), "http://localhost:8080/api/users/123?q=bar")
+	st.Expect(t, hr.Header.Get("X-Client"), "foo")
+	st.Expect(t, hr.Header.Get("X-Dial"), "before")
+	st.Expect(t, hr.Header.Get("Content-Type"), "application/json")
+	body, _ := ioutil.ReadAll(hr.Body)
+	st.Expect(t, string(body), "{\"a\":1}\n")
+
+	client, hr, err := req.BuildClient()
+	st.Expect(t, err, nil)
+	st.Expect(t, client.Timeout, time.Second)
+	st.Expect(t, hr.URL.Path, "/api/users/123")
+
+	// The request remains untouched
+	st.Expect(t, req.Context.Client.Timeout, time.Duration(0))
+	st.Expect(t, req.Context.Request.Header.Get("X-Dial"), "")
+	st.Expect(t, req.Context.Request.URL.String(), "")
+}
+
+func TestRequestBuildStop(t *testing.T) {
+	var acquired int32
+	req := NewRequest().URL("http://localhost")
+	req.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		st.Expect(t, ctx.Get("$dryrun"), true)
+		atomic.AddInt32(&acquired, 1)
+		h.Next(ctx)
+	})
+	req.UseHandler("stop", func(ctx *context.Context, h context.Handler) {
+		atomic.AddInt32(&acquired, -1)
+		h.Next(ctx)
+	})
+
+	// The stop phase releases the resources acquired by the middleware
+	for i := 0; i < 3; i++ {
+		_, err := req.Build()
+		st.Expect(t, err, nil)
+		st.Expect(t, atomic.LoadInt32(&acquired), int32(0))
+	}
+}
+
+func TestRequestBuildError(t *testing.T) {
+	req := NewRequest().URL("http://localhost")
+	req.UseHandler("before dial", func(ctx *context.Context, h context.Handler) {
+		h.Error(ctx, errors.New("oops"))
+	})
+	_, err := req.Build()
+	st.Expect(t, err.Error(), "oops")
+}
+
 func BenchmarkSimpleRequestGet(b *testing.B) {
 	ts := createEchoServer()
 	defer ts.Close()
@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"time"
 
 	"gopkg.in/h2non/gentleman.v2/context"
 	"gopkg.in/h2non/gentleman.v2/utils"
@@ -49,6 +50,18 @@ type Response struct {
 	// Expose original request Context for convenience.
 	Context *context.Context
 
+	// Stale reports whether the response was served from a stored response
+	// because the server could not provide a fresh one.
+	// Plugins flag stale responses via the "$stale" context store key.
+	Stale bool
+
+	// Age stores how old the response is, if it was served from a stored response.
+	// Plugins report it via the "$age" context store key.
+	Age time.Duration
+
+	// Timings stores the network and middleware phase timings of the HTTP transaction.
+	Timings *Timings
+
 	// Internal buffer store
 	buffer *bytes.Buffer
 }
@@ -72,6 +85,10 @@ func buildResponse(ctx *context.Context) (*Response, error) {
 		buffer:      bytes.NewBuffer([]byte{}),
 	}
 
+	res.Stale, _ = ctx.Get("$stale").(bool)
+	res.Age, _ = ctx.Get("$age").(time.Duration)
+	res.Timings, _ = ctx.Get("$timings").(*Timings)
+
 	return res, res.Error
 }
 
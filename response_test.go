@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/nbio/st"
 	"gopkg.in/h2non/gentleman.v2/utils"
@@ -39,6 +40,20 @@ func TestResponseBuild(t *testing.T) {
 	}
 }
 
+func TestResponseBuildStale(t *testing.T) {
+	ctx := NewContext()
+	ctx.Response.StatusCode = 200
+	res, _ := buildResponse(ctx)
+	st.Expect(t, res.Stale, false)
+	st.Expect(t, res.Age, time.Duration(0))
+
+	ctx.Set("$stale", true)
+	ctx.Set("$age", time.Minute)
+	res, _ = buildResponse(ctx)
+	st.Expect(t, res.Stale, true)
+	st.Expect(t, res.Age, time.Minute)
+}
+
 func TestResponseBuildStatusCodes(t *testing.T) {
 	cases := []struct {
 		code   int
@@ -0,0 +1,175 @@
+package gentleman
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+)
+
+// ConnStats stores the connection statistics of a host.
+type ConnStats struct {
+	// Open stores the number of open connections, either idle or in use.
+	Open int
+
+	// Idle stores the number of connections kept alive in the pool, ready to be reused.
+	Idle int
+
+	// InUse stores the number of connections serving a request.
+	InUse int
+}
+
+// trackedConn wraps a dialed connection, forgetting it once closed.
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+	once    sync.Once
+
+	// Guarded by the tracker lock
+	host  string
+	inUse bool
+}
+
+// Close implements the net.Conn interface.
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { c.tracker.forget(c) })
+	return c.Conn.Close()
+}
+
+// connTracker tracks the connections used by a client.
+//
+// The dialer of the transports owned by the client is wrapped, so connections
+// are tracked since dialed until closed, while httptrace connection events
+// report whether they're in use or idle in the pool.
+// HTTP/2 connections are never returned to the pool, so they're reported as in use.
+type connTracker struct {
+	mtx   sync.Mutex
+	conns map[*trackedConn]bool
+}
+
+// newConnTracker creates a new empty connection tracker.
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[*trackedConn]bool)}
+}
+
+// setup wraps the dialer of the given transport to track its connections.
+func (t *connTracker) setup(transport *http.Transport) {
+	dial := transport.DialContext
+	if dial == nil && transport.Dial != nil {
+		dialer := transport.Dial
+		dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+			return dialer(network, addr)
+		}
+	}
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = t.dialer(dial)
+}
+
+// dialer wraps the given dial function to track the dialed connections.
+// Connections already tracked, dialed by a wrapped transport dialer, are returned as is.
+func (t *connTracker) dialer(dial func(context.Context, string, string) (net.Conn, error)) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if tc, ok := conn.(*trackedConn); ok && tc.tracker == t {
+			return tc, nil
+		}
+
+		tc := &trackedConn{Conn: conn, tracker: t, host: addr}
+		t.mtx.Lock()
+		t.conns[tc] = true
+		t.mtx.Unlock()
+		return tc, nil
+	}
+}
+
+// trace returns the httptrace.ClientTrace hooks that track the state
+// of the connection used by a single HTTP transaction.
+func (t *connTracker) trace() *httptrace.ClientTrace {
+	var mtx sync.Mutex
+	var host string
+	var conn net.Conn
+
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			mtx.Lock()
+			host = hostPort
+			mtx.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			mtx.Lock()
+			conn = info.Conn
+			hostPort := host
+			mtx.Unlock()
+			t.update(info.Conn, hostPort, true)
+		},
+		PutIdleConn: func(error) {
+			mtx.Lock()
+			c := conn
+			mtx.Unlock()
+			t.update(c, "", false)
+		},
+	}
+}
+
+// update updates the state of the given connection, if tracked.
+// Connections not returned to the pool are closed, and therefore forgotten.
+func (t *connTracker) update(conn net.Conn, host string, inUse bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	tc := t.lookup(conn)
+	if tc == nil {
+		return
+	}
+	if host != "" {
+		tc.host = host
+	}
+	tc.inUse = inUse
+}
+
+// lookup returns the tracked connection of the given connection, such as a TLS one.
+// Must be called with the lock held.
+func (t *connTracker) lookup(conn net.Conn) *trackedConn {
+	for conn != nil {
+		if tc, ok := conn.(*trackedConn); ok && t.conns[tc] {
+			return tc
+		}
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil
+		}
+		conn = wrapper.NetConn()
+	}
+	return nil
+}
+
+// forget stops tracking the given connection.
+func (t *connTracker) forget(tc *trackedConn) {
+	t.mtx.Lock()
+	delete(t.conns, tc)
+	t.mtx.Unlock()
+}
+
+// stats returns the connection statistics per host.
+func (t *connTracker) stats() map[string]ConnStats {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	stats := make(map[string]ConnStats)
+	for tc := range t.conns {
+		s := stats[tc.host]
+		s.Open++
+		if tc.inUse {
+			s.InUse++
+		} else {
+			s.Idle++
+		}
+		stats[tc.host] = s
+	}
+	return stats
+}
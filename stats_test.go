@@ -0,0 +1,167 @@
+package gentleman
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2/plugins/pool"
+)
+
+func TestClientStats(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	cli := New()
+	cli.URL(ts.URL)
+	cli.Use(pool.Config(pool.Options{MaxIdleConnsPerHost: 2, IdleConnTimeout: time.Hour}))
+	st.Expect(t, len(cli.Stats()), 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cli.Request().Send()
+			st.Expect(t, err, nil)
+			st.Expect(t, res.String(), "ok")
+		}()
+	}
+
+	// Concurrent requests use their own connection
+	waitForStats(t, cli, host, ConnStats{Open: 3, InUse: 3})
+	close(release)
+	wg.Wait()
+
+	// Connections exceeding the idle pool size are closed
+	waitForStats(t, cli, host, ConnStats{Open: 2, Idle: 2})
+}
+
+func TestClientStatsReuse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	cli := New()
+	cli.URL(ts.URL)
+	cli.Use(pool.Config(pool.Options{IdleConnTimeout: 100 * time.Millisecond}))
+
+	for i := 0; i < 3; i++ {
+		res, err := cli.Request().Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "ok")
+		waitForStats(t, cli, host, ConnStats{Open: 1, Idle: 1})
+	}
+
+	// Idle connections are closed after the idle timeout
+	waitForStats(t, cli, host, ConnStats{})
+	st.Expect(t, len(cli.Stats()), 0)
+
+	// Clients created with no constructor report no stats
+	st.Expect(t, len((&Client{}).Stats()), 0)
+}
+
+func TestClientStatsSharedTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// Connections of the shared default transport are not tracked
+	cli := New()
+	cli.URL(ts.URL)
+	res, err := cli.Request().Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "ok")
+	st.Expect(t, len(cli.Stats()), 0)
+}
+
+func TestClientStatsConnectionClose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	cli := New()
+	cli.URL(ts.URL)
+	cli.Use(pool.Config(pool.Options{}))
+	for i := 0; i < 20; i++ {
+		res, err := cli.Request().Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "ok")
+	}
+
+	// Connections closed instead of returned to the pool are forgotten
+	waitForStats(t, cli, host, ConnStats{})
+	st.Expect(t, len(cli.Stats()), 0)
+}
+
+func TestClientStatsServerClose(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	ts.Config.IdleTimeout = 200 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	cli := New()
+	cli.URL(ts.URL)
+	cli.Use(pool.Config(pool.Options{IdleConnTimeout: time.Hour}))
+
+	res, err := cli.Request().Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "ok")
+	waitForStats(t, cli, host, ConnStats{Open: 1, Idle: 1})
+
+	// Idle connections closed by the server are forgotten
+	waitForStats(t, cli, host, ConnStats{})
+	st.Expect(t, len(cli.Stats()), 0)
+}
+
+func TestClientsConnectionReuse(t *testing.T) {
+	var conns int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	// Short-lived clients share the connections of the default transport
+	for i := 0; i < 50; i++ {
+		res, err := New().URL(ts.URL).Request().Send()
+		st.Expect(t, err, nil)
+		st.Expect(t, res.String(), "ok")
+	}
+	st.Expect(t, atomic.LoadInt32(&conns), int32(1))
+}
+
+func waitForStats(t *testing.T, cli *Client, host string, stats ConnStats) {
+	deadline := time.Now().Add(5 * time.Second)
+	for cli.Stats()[host] != stats {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected stats: %+v", cli.Stats()[host])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
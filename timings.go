@@ -0,0 +1,170 @@
+package gentleman
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings stores the network and middleware timings of an HTTP transaction.
+//
+// If the request is retried, the network timings refer to the last attempt.
+type Timings struct {
+	// DNSLookup stores the time spent resolving the host name.
+	DNSLookup time.Duration
+
+	// Connect stores the time spent establishing the TCP connection.
+	Connect time.Duration
+
+	// TLSHandshake stores the time spent in the TLS handshake.
+	TLSHandshake time.Duration
+
+	// Blocked stores the time spent waiting for an available connection,
+	// excluding the DNS lookup, connect and TLS handshake of new connections.
+	Blocked time.Duration
+
+	// Send stores the time spent writing the request.
+	Send time.Duration
+
+	// Wait stores the time since the request was written
+	// until the first response byte was received.
+	Wait time.Duration
+
+	// TimeToFirstByte stores the time since a connection was requested
+	// until the first response byte was received.
+	TimeToFirstByte time.Duration
+
+	// BodyRead stores the time spent reading the response body,
+	// since the first read until the body is fully read or closed.
+	BodyRead time.Duration
+
+	// ConnReused reports whether the connection was reused from a previous request.
+	ConnReused bool
+
+	// RemoteAddr stores the network address of the server connection, if any.
+	RemoteAddr string
+
+	// Phases stores the time spent in each middleware phase, such as
+	// "request", "before dial", "after dial" or "response".
+	Phases map[string]time.Duration
+}
+
+// newTimings creates a new empty Timings.
+func newTimings() *Timings {
+	return &Timings{Phases: make(map[string]time.Duration)}
+}
+
+// tracer collects the network timings of a single HTTP transaction.
+// Trace hooks may be called from other goroutines, even after the
+// transaction finishes (e.g: racing dials), so access is synchronized.
+type tracer struct {
+	mtx                    sync.Mutex
+	getConn, gotConn       time.Time
+	wroteRequest           time.Time
+	firstByte              time.Time
+	dnsStart, dnsDone      time.Time
+	connectStart, connDone time.Time
+	tlsStart, tlsDone      time.Time
+	reused                 bool
+	addr                   string
+}
+
+// trace returns the httptrace.ClientTrace hooks that feed the tracer.
+func (t *tracer) trace() *httptrace.ClientTrace {
+	record := func(field *time.Time) {
+		t.mtx.Lock()
+		*field = time.Now()
+		t.mtx.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// A new attempt resets the timings of previous ones
+			t.mtx.Lock()
+			t.getConn, t.gotConn = time.Now(), time.Time{}
+			t.wroteRequest, t.firstByte = time.Time{}, time.Time{}
+			t.dnsStart, t.dnsDone = time.Time{}, time.Time{}
+			t.connectStart, t.connDone = time.Time{}, time.Time{}
+			t.tlsStart, t.tlsDone = time.Time{}, time.Time{}
+			t.reused, t.addr = false, ""
+			t.mtx.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mtx.Lock()
+			t.gotConn, t.reused = time.Now(), info.Reused
+			if info.Conn != nil {
+				t.addr = info.Conn.RemoteAddr().String()
+			}
+			t.mtx.Unlock()
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { record(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { record(&t.dnsDone) },
+		ConnectStart:         func(string, string) { record(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { record(&t.connDone) },
+		TLSHandshakeStart:    func() { record(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { record(&t.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&t.wroteRequest) },
+		GotFirstResponseByte: func() { record(&t.firstByte) },
+	}
+}
+
+// collect copies the collected network timings into the given Timings.
+func (t *tracer) collect(timings *Timings) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	timings.DNSLookup = since(t.dnsStart, t.dnsDone)
+	timings.Connect = since(t.connectStart, t.connDone)
+	timings.TLSHandshake = since(t.tlsStart, t.tlsDone)
+	timings.Send = since(t.gotConn, t.wroteRequest)
+	timings.Wait = since(t.wroteRequest, t.firstByte)
+	timings.TimeToFirstByte = since(t.getConn, t.firstByte)
+	timings.ConnReused = t.reused
+	timings.RemoteAddr = t.addr
+
+	// The connection setup is not considered as blocked time
+	timings.Blocked = since(t.getConn, t.gotConn) - timings.DNSLookup - timings.Connect - timings.TLSHandshake
+	if timings.Blocked < 0 {
+		timings.Blocked = 0
+	}
+}
+
+func since(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// timedBody records the time spent reading the response body.
+type timedBody struct {
+	io.ReadCloser
+	timings *Timings
+	start   time.Time
+	done    bool
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	if b.start.IsZero() {
+		b.start = time.Now()
+	}
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *timedBody) finish() {
+	if b.done || b.start.IsZero() {
+		return
+	}
+	b.done = true
+	b.timings.BodyRead = time.Since(b.start)
+}
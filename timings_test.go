@@ -0,0 +1,116 @@
+package gentleman
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestResponseTimings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintln(w, "Hello, world")
+	}))
+	defer ts.Close()
+
+	cli := New()
+	cli.UseRequest(func(ctx *context.Context, h context.Handler) {
+		time.Sleep(5 * time.Millisecond)
+		h.Next(ctx)
+	})
+
+	res, err := cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "Hello, world\n")
+
+	timings := res.Timings
+	st.Reject(t, timings, nil)
+	st.Expect(t, timings.ConnReused, false)
+	st.Expect(t, timings.Connect > 0, true)
+	st.Expect(t, timings.TimeToFirstByte >= 10*time.Millisecond, true)
+	st.Expect(t, timings.Send > 0, true)
+	st.Expect(t, timings.Wait >= 10*time.Millisecond, true)
+	st.Expect(t, timings.RemoteAddr, ts.Listener.Addr().String())
+	st.Expect(t, timings.Phases["request"] >= 5*time.Millisecond, true)
+	for _, phase := range []string{"before dial", "after dial", "response"} {
+		_, ok := timings.Phases[phase]
+		st.Expect(t, ok, true)
+	}
+
+	// Subsequent requests reuse the connection
+	res, err = cli.Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "Hello, world\n")
+	st.Expect(t, res.Timings.ConnReused, true)
+	st.Expect(t, res.Timings.Connect, time.Duration(0))
+}
+
+func TestResponseTimingsBodyRead(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello"))
+		w.(http.Flusher).Flush()
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(", world"))
+	}))
+	defer ts.Close()
+
+	res, err := New().Request().URL(ts.URL).Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.String(), "Hello, world")
+	st.Expect(t, res.Timings.BodyRead >= 5*time.Millisecond, true)
+}
+
+func TestResponseTimingsUpgrade(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		buf.Flush()
+		line, _ := buf.ReadString('\n')
+		buf.WriteString(line)
+		buf.Flush()
+	}))
+	defer ts.Close()
+
+	res, err := New().Request().URL(ts.URL).
+		SetHeader("Connection", "Upgrade").
+		SetHeader("Upgrade", "echo").
+		Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.StatusCode, 101)
+
+	// The upgraded connection remains writable
+	conn, ok := res.RawResponse.Body.(io.ReadWriteCloser)
+	st.Expect(t, ok, true)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping\n"))
+	st.Expect(t, err, nil)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	st.Expect(t, err, nil)
+	st.Expect(t, line, "ping\n")
+}
+
+func TestResponseTimingsIntercepted(t *testing.T) {
+	req := NewRequest()
+	req.UseRequest(func(ctx *context.Context, h context.Handler) {
+		ctx.Response.StatusCode = 200
+		h.Next(ctx)
+	})
+
+	res, err := req.Send()
+	st.Expect(t, err, nil)
+	st.Expect(t, res.Timings.TimeToFirstByte, time.Duration(0))
+	_, ok := res.Timings.Phases["intercept"]
+	st.Expect(t, ok, true)
+}
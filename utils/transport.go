@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+// TransportsKey stores the context store key of the TransportCache owned by a client.
+const TransportsKey = "$transports"
+
+// IdleConnTimeout defines the idle connections timeout of the derived transports with none,
+// so the connections of transports no longer used are eventually closed.
+var IdleConnTimeout = 90 * time.Second
+
+// DefaultTransportCache stores the transports derived by the plugins of requests with no client.
+var DefaultTransportCache = NewTransportCache()
+
+// TransportCache memoizes the copy-on-write clones of the HTTP transports modified by plugins,
+// so shared transports, such as the default one, are never modified in place,
+// while the derived transports and their connection pools are reused across requests.
+//
+// Derived transports are memoized by base transport and by a comparable key identifying
+// the modification, so plugins created per request with the same settings reuse
+// the same transport, instead of creating a new connection pool per request.
+type TransportCache struct {
+	// Setup is optionally called on every derived transport once modified,
+	// such as to track its connections. It must be defined before use.
+	Setup func(*http.Transport)
+
+	mtx           sync.Mutex
+	transports    map[transportKey]*http.Transport
+	roundTrippers map[transportKey]http.RoundTripper
+}
+
+// transportKey identifies a modification of a base transport.
+type transportKey struct {
+	base *http.Transport
+	key  interface{}
+}
+
+// NewTransportCache creates a new empty TransportCache.
+func NewTransportCache() *TransportCache {
+	return &TransportCache{
+		transports:    make(map[transportKey]*http.Transport),
+		roundTrippers: make(map[transportKey]http.RoundTripper),
+	}
+}
+
+// Transports returns the TransportCache owned by the client of the given context,
+// or DefaultTransportCache if there is none.
+func Transports(ctx *context.Context) *TransportCache {
+	if cache, ok := ctx.Get(TransportsKey).(*TransportCache); ok {
+		return cache
+	}
+	return DefaultTransportCache
+}
+
+// Derive returns the clone of the given transport modified by fn,
+// creating and memoizing it on first use for the given key.
+// The key must be comparable and identify the modification performed by fn.
+// The clone expires its idle connections after IdleConnTimeout, if it has no timeout.
+func (c *TransportCache) Derive(base *http.Transport, key interface{}, fn func(*http.Transport)) *http.Transport {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	k := transportKey{base, key}
+	if transport, ok := c.transports[k]; ok {
+		return transport
+	}
+
+	transport := base.Clone()
+	if transport.IdleConnTimeout == 0 {
+		transport.IdleConnTimeout = IdleConnTimeout
+	}
+	fn(transport)
+	if c.Setup != nil {
+		c.Setup(transport)
+	}
+	c.transports[k] = transport
+	return transport
+}
+
+// RoundTripper returns the http.RoundTripper built by fn on top of the given transport,
+// creating and memoizing it on first use for the given key.
+// The key must be comparable and identify the round tripper built by fn.
+func (c *TransportCache) RoundTripper(base *http.Transport, key interface{}, fn func(*http.Transport) http.RoundTripper) http.RoundTripper {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	k := transportKey{base, key}
+	if rt, ok := c.roundTrippers[k]; ok {
+		return rt
+	}
+
+	rt := fn(base)
+	c.roundTrippers[k] = rt
+	return rt
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gentleman.v2/context"
+)
+
+func TestTransportCache(t *testing.T) {
+	base := &http.Transport{}
+	cache := NewTransportCache()
+	calls := 0
+
+	var wg sync.WaitGroup
+	transports := make([]*http.Transport, 10)
+	for i := range transports {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			transports[i] = cache.Derive(base, "compression", func(transport *http.Transport) {
+				calls++
+				transport.DisableCompression = true
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	st.Expect(t, calls, 1)
+	st.Expect(t, base.DisableCompression, false)
+	for _, transport := range transports {
+		st.Expect(t, transport, transports[0])
+		st.Expect(t, transport != base, true)
+		st.Expect(t, transport.DisableCompression, true)
+	}
+
+	other := &http.Transport{}
+	st.Expect(t, cache.Derive(other, "compression", func(*http.Transport) { calls++ }) != transports[0], true)
+	st.Expect(t, calls, 2)
+
+	// Other modifications of the same transport derive another one
+	proxied := cache.Derive(base, "proxy", func(*http.Transport) { calls++ })
+	st.Expect(t, proxied != transports[0], true)
+	st.Expect(t, proxied.DisableCompression, false)
+	st.Expect(t, calls, 3)
+}
+
+func TestTransportCacheSetup(t *testing.T) {
+	cache := NewTransportCache()
+	var setup []*http.Transport
+	cache.Setup = func(transport *http.Transport) {
+		setup = append(setup, transport)
+	}
+
+	// Derived transports always expire their idle connections
+	transport := cache.Derive(&http.Transport{}, "compression", func(*http.Transport) {})
+	st.Expect(t, transport.IdleConnTimeout, IdleConnTimeout)
+	st.Expect(t, setup, []*http.Transport{transport})
+
+	custom := cache.Derive(&http.Transport{IdleConnTimeout: time.Second}, "compression", func(*http.Transport) {})
+	st.Expect(t, custom.IdleConnTimeout, time.Second)
+	st.Expect(t, len(setup), 2)
+}
+
+func TestTransportCacheRoundTripper(t *testing.T) {
+	base := &http.Transport{}
+	cache := NewTransportCache()
+	calls := 0
+
+	build := func(transport *http.Transport) http.RoundTripper {
+		calls++
+		st.Expect(t, transport, base)
+		return &http.Transport{}
+	}
+	rt := cache.RoundTripper(base, "h2c", build)
+	st.Expect(t, cache.RoundTripper(base, "h2c", build), rt)
+	st.Expect(t, calls, 1)
+}
+
+func TestTransports(t *testing.T) {
+	ctx := context.New()
+	st.Expect(t, Transports(ctx), DefaultTransportCache)
+
+	cache := NewTransportCache()
+	parent := context.New()
+	parent.Set(TransportsKey, cache)
+	ctx.UseParent(parent)
+	st.Expect(t, Transports(ctx), cache)
+}
@@ -27,6 +27,13 @@ func WriteBodyString(res *http.Response, body string) {
 	res.ContentLength = int64(len(body))
 }
 
+// WriteBodyBytes writes a byte slice based body in a given http.Response.
+// Each call creates an independent reader over the given data.
+func WriteBodyBytes(res *http.Response, body []byte) {
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+}
+
 // StringReader creates an io.ReadCloser interface from a string.
 func StringReader(body string) io.ReadCloser {
 	b := bytes.NewReader([]byte(body))
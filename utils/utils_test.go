@@ -34,3 +34,18 @@ func TestWriteBodyString(t *testing.T) {
 		t.Fatal("Invalid body data")
 	}
 }
+
+func TestWriteBodyBytes(t *testing.T) {
+	res := &http.Response{}
+	body := []byte("hello world")
+	WriteBodyBytes(res, body)
+
+	if res.ContentLength != int64(len(body)) {
+		t.Fatalf("Invalid content length: %d", res.ContentLength)
+	}
+
+	contents, _ := ioutil.ReadAll(res.Body)
+	if string(contents) != string(body) {
+		t.Fatal("Invalid body data")
+	}
+}